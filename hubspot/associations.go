@@ -0,0 +1,131 @@
+package hubspot
+
+/*
+
+example:
+
+deal, err := crm.Deals().Create(hubspot.Properties{
+    "dealname":  "Acme - Pro plan",
+    "pipeline":  "default",
+    "dealstage": "appointmentscheduled",
+})
+...
+err = crm.Associate("deals", deal.Id, "companies", companyId)
+err = crm.Associate("deals", deal.Id, "contacts", contact.Id)
+
+*/
+
+import (
+	"fmt"
+	"net/url"
+)
+
+//------------------------------------------------------------
+// Constants
+//------------------------------------------------------------
+
+const (
+	hubAssocUrl        = hubApiUrl + "/crm/v4/objects/%s/%s/associations/%s"
+	hubAssocDefaultUrl = hubApiUrl + "/crm/v4/objects/%s/%s/associations/default/%s/%s"
+)
+
+//------------------------------------------------------------
+// Associations model
+//------------------------------------------------------------
+
+// Association links object to another object of given type.
+type Association struct {
+	ToObjectId int64             `json:"toObjectId"`
+	Types      []AssociationType `json:"associationTypes"`
+}
+
+type AssociationType struct {
+	Category string `json:"category"` // HUBSPOT_DEFINED, USER_DEFINED
+	TypeId   int    `json:"typeId"`
+	Label    string `json:"label"`
+}
+
+type associationPage struct {
+	Results []Association `json:"results"`
+	Paging  *Paging       `json:"paging,omitempty"`
+}
+
+// AssociationIter iterates over associations across all result pages.
+type AssociationIter struct {
+	crm   *CRM
+	url   string
+	after string
+	items []Association
+	cur   Association
+	done  bool
+	err   error
+}
+
+//------------------------------------------------------------
+// CRM methods: Associations
+//------------------------------------------------------------
+
+// Links two objects with default association type,
+// ie Associate("deals", dealId, "companies", companyId).
+func (crm *CRM) Associate(fromType, fromId, toType, toId string) error {
+	u := fmt.Sprintf(hubAssocDefaultUrl, fromType, fromId, toType, toId)
+	return crm.sendRequest("PUT", u, nil, nil)
+}
+
+// Removes all associations between two objects.
+func (crm *CRM) Dissociate(fromType, fromId, toType, toId string) error {
+	u := fmt.Sprintf(hubAssocUrl, fromType, fromId, toType) + "/" + toId
+	return crm.sendRequest("DELETE", u, nil, nil)
+}
+
+// Lists objects of toType associated with object.
+// Pages are fetched lazily as the iterator advances.
+func (crm *CRM) Associations(fromType, fromId, toType string) *AssociationIter {
+	return &AssociationIter{
+		crm: crm,
+		url: fmt.Sprintf(hubAssocUrl, fromType, fromId, toType),
+	}
+}
+
+//------------------------------------------------------------
+// AssociationIter methods
+//------------------------------------------------------------
+
+// Advances to the next association, fetching next page when needed.
+// Returns false when done or on error, see Err.
+func (it *AssociationIter) Next() bool {
+
+	for len(it.items) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+
+		v := url.Values{}
+		v.Set("limit", "500")
+		if it.after != "" {
+			v.Set("after", it.after)
+		}
+
+		var pg associationPage
+		if it.err = it.crm.sendRequest("GET", it.url+"?"+v.Encode(), nil, &pg); it.err != nil {
+			return false
+		}
+
+		it.items = pg.Results
+		it.after = pg.Paging.NextAfter()
+		it.done = it.after == ""
+	}
+
+	it.cur, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// Current association.
+func (it *AssociationIter) Association() Association {
+	return it.cur
+}
+
+// First error encountered while fetching pages, if any.
+func (it *AssociationIter) Err() error {
+	return it.err
+}
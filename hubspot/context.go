@@ -0,0 +1,163 @@
+package hubspot
+
+/*
+
+example:
+
+ctx := hubspot.NewContext(r, hubspot.ContextOptions{
+    PageName:       "Signup",
+    TrustedProxies: []string{"10.0.0.0/8", "127.0.0.1"},
+})
+
+resp, err := hubspot.SubmitV3(portalId, formId, hubspot.Submission{
+    Fields:  fields,
+    Context: &ctx,
+})
+
+*/
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+//------------------------------------------------------------
+// Context from request
+//------------------------------------------------------------
+
+// ContextOptions tune how Context is built from request.
+type ContextOptions struct {
+	PageName string // overrides empty page name
+	PageUri  string // overrides uri reconstructed from request
+
+	// Proxies (IPs or CIDRs) whose X-Forwarded-For, X-Forwarded-Proto
+	// and X-Forwarded-Host headers are trusted. Headers are ignored
+	// when request does not come from a trusted proxy.
+	TrustedProxies []string
+}
+
+// Builds submission context from incoming request.
+// The hubspotutk cookie is optional, context is built without it.
+func NewContext(r *http.Request, opts ContextOptions) (ctx Context) {
+
+	trusted := parseNets(opts.TrustedProxies)
+
+	if cookie, err := r.Cookie("hubspotutk"); err == nil {
+		ctx.Hutk = cookie.Value
+	}
+
+	ctx.IpAddress = clientIP(r, trusted)
+	ctx.PageName = opts.PageName
+
+	ctx.PageUri = opts.PageUri
+	if ctx.PageUri == "" {
+		ctx.PageUri = pageUri(r, trusted)
+	}
+
+	return
+}
+
+//------------------------------------------------------------
+// Private methods
+//------------------------------------------------------------
+
+// Client IP: remote address, or when it is a trusted proxy
+// the rightmost untrusted X-Forwarded-For entry.
+func clientIP(r *http.Request, trusted []*net.IPNet) string {
+
+	ip := remoteIP(r)
+	if !isTrusted(ip, trusted) {
+		return ip
+	}
+
+	var hops []string
+	for _, h := range r.Header["X-Forwarded-For"] {
+		for _, hop := range strings.Split(h, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		if !isTrusted(hops[i], trusted) {
+			return hops[i]
+		}
+		ip = hops[i]
+	}
+
+	return ip
+}
+
+// Absolute page uri with scheme and host.
+func pageUri(r *http.Request, trusted []*net.IPNet) string {
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	host := r.Host
+
+	if isTrusted(remoteIP(r), trusted) {
+		if proto := firstHeaderValue(r, "X-Forwarded-Proto"); proto != "" {
+			scheme = strings.ToLower(proto)
+		}
+		if fwdHost := firstHeaderValue(r, "X-Forwarded-Host"); fwdHost != "" {
+			host = fwdHost
+		}
+	}
+
+	return scheme + "://" + host + r.URL.RequestURI()
+}
+
+// Remote address without port.
+func remoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// First comma separated value of header.
+func firstHeaderValue(r *http.Request, name string) string {
+	return strings.TrimSpace(strings.Split(r.Header.Get(name), ",")[0])
+}
+
+func isTrusted(ip string, trusted []*net.IPNet) bool {
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, n := range trusted {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Parses IPs and CIDRs, invalid entries are skipped.
+func parseNets(ss []string) (nets []*net.IPNet) {
+
+	for _, s := range ss {
+		if !strings.Contains(s, "/") {
+			if ip := net.ParseIP(s); ip != nil {
+				bits := 8 * len(ip.To16())
+				if ip.To4() != nil {
+					ip, bits = ip.To4(), 32
+				}
+				nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			}
+			continue
+		}
+		if _, n, err := net.ParseCIDR(s); err == nil {
+			nets = append(nets, n)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,403 @@
+package hubspot
+
+/*
+
+example:
+
+crm := hubspot.NewCRM("pat-na1-XXXX") // private app token
+
+contact, err := crm.Contacts().GetByEmail("jo@example.com", "lifecyclestage")
+if err != nil {
+    ...
+}
+fmt.Print(contact.Properties["lifecyclestage"])
+
+_, err = crm.Contacts().Update(contact.Id, hubspot.Properties{"plan": "pro"})
+
+*/
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+//------------------------------------------------------------
+// Constants
+//------------------------------------------------------------
+
+const (
+	hubApiUrl     = "https://api.hubapi.com"
+	hubObjectsUrl = hubApiUrl + "/crm/v3/objects/%s"
+)
+
+//------------------------------------------------------------
+// CRM
+//------------------------------------------------------------
+
+// CRM is HubSpot CRM v3 API client authenticated
+// with private app access token or OAuth, see NewCRMOAuth.
+type CRM struct {
+	Token      string       // private app access token
+	Auth       Authorizer   // used instead of Token when set
+	BaseUrl    string       // optional, replaces https://api.hubapi.com, ie for tests
+	HTTPClient *http.Client // optional, defaults to http.DefaultClient
+}
+
+func NewCRM(token string) *CRM {
+	return &CRM{Token: token}
+}
+
+//------------------------------------------------------------
+// CRM model
+//------------------------------------------------------------
+
+// Properties of a CRM object, HubSpot transfers all values as strings.
+type Properties map[string]string
+
+// Object is a CRM record: contact, company, deal etc.
+type Object struct {
+	Id         string     `json:"id"`
+	Properties Properties `json:"properties"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+	Archived   bool       `json:"archived"`
+}
+
+// Single record of a batch upsert.
+type UpsertInput struct {
+	Id         string     `json:"id"` // value of idProperty
+	IdProperty string     `json:"idProperty,omitempty"`
+	Properties Properties `json:"properties"`
+}
+
+// Search request, filter groups are OR-ed, filters within group AND-ed.
+type SearchRequest struct {
+	FilterGroups []FilterGroup `json:"filterGroups,omitempty"`
+	Sorts        []Sort        `json:"sorts,omitempty"`
+	Query        string        `json:"query,omitempty"`
+	Properties   []string      `json:"properties,omitempty"`
+	Limit        int           `json:"limit,omitempty"`
+	After        string        `json:"after,omitempty"`
+}
+
+type FilterGroup struct {
+	Filters []Filter `json:"filters"`
+}
+
+// Operators: EQ, NEQ, LT, LTE, GT, GTE, BETWEEN, IN, NOT_IN,
+// HAS_PROPERTY, NOT_HAS_PROPERTY, CONTAINS_TOKEN, NOT_CONTAINS_TOKEN.
+type Filter struct {
+	PropertyName string   `json:"propertyName"`
+	Operator     string   `json:"operator"`
+	Value        string   `json:"value,omitempty"`
+	HighValue    string   `json:"highValue,omitempty"`
+	Values       []string `json:"values,omitempty"`
+}
+
+type Sort struct {
+	PropertyName string `json:"propertyName"`
+	Direction    string `json:"direction"` // ASCENDING, DESCENDING
+}
+
+// Page of search or list results.
+type SearchResult struct {
+	Total   int      `json:"total"`
+	Results []Object `json:"results"`
+	Paging  *Paging  `json:"paging,omitempty"`
+}
+
+type Paging struct {
+	Next *struct {
+		After string `json:"after"`
+	} `json:"next,omitempty"`
+}
+
+// Cursor of the next page, empty on last page.
+func (p *Paging) NextAfter() string {
+	if p == nil || p.Next == nil {
+		return ""
+	}
+	return p.Next.After
+}
+
+//------------------------------------------------------------
+// Objects
+//------------------------------------------------------------
+
+// Objects is API of one CRM object type.
+type Objects struct {
+	crm     *CRM
+	objType string
+}
+
+// Contacts API.
+type Contacts struct {
+	*Objects
+}
+
+func (crm *CRM) Contacts() Contacts {
+	return Contacts{crm.Objects("contacts")}
+}
+
+// Companies API.
+func (crm *CRM) Companies() *Objects {
+	return crm.Objects("companies")
+}
+
+// Deals API.
+func (crm *CRM) Deals() *Objects {
+	return crm.Objects("deals")
+}
+
+// API of any object type, ie "contacts", "p_custom".
+func (crm *CRM) Objects(objType string) *Objects {
+	return &Objects{crm: crm, objType: objType}
+}
+
+// Creates object.
+func (o *Objects) Create(props Properties) (obj *Object, err error) {
+
+	obj = &Object{}
+	if err = o.crm.sendRequest("POST", o.url(""), map[string]interface{}{"properties": props}, obj); err != nil {
+		obj = nil
+	}
+
+	return
+}
+
+// Updates given properties of object.
+func (o *Objects) Update(id string, props Properties) (obj *Object, err error) {
+
+	obj = &Object{}
+	if err = o.crm.sendRequest("PATCH", o.url(url.PathEscape(id)), map[string]interface{}{"properties": props}, obj); err != nil {
+		obj = nil
+	}
+
+	return
+}
+
+// Gets object by id with given properties,
+// HubSpot default properties when none given.
+func (o *Objects) Get(id string, properties ...string) (obj *Object, err error) {
+	return o.GetBy("", id, properties...)
+}
+
+// Gets object by unique property value, ie email.
+func (o *Objects) GetBy(idProperty, value string, properties ...string) (obj *Object, err error) {
+
+	v := url.Values{}
+	if idProperty != "" {
+		v.Set("idProperty", idProperty)
+	}
+	if len(properties) > 0 {
+		v.Set("properties", strings.Join(properties, ","))
+	}
+
+	u := o.url(url.PathEscape(value))
+	if len(v) > 0 {
+		u += "?" + v.Encode()
+	}
+
+	obj = &Object{}
+	if err = o.crm.sendRequest("GET", u, nil, obj); err != nil {
+		obj = nil
+	}
+
+	return
+}
+
+// Archives object.
+func (o *Objects) Archive(id string) error {
+	return o.crm.sendRequest("DELETE", o.url(url.PathEscape(id)), nil, nil)
+}
+
+// Creates or updates objects matched by idProperty (max 100 per call).
+// Caller's inputs are not modified.
+func (o *Objects) BatchUpsert(idProperty string, inputs []UpsertInput) (objs []Object, err error) {
+
+	ins := make([]UpsertInput, len(inputs))
+	copy(ins, inputs)
+	for i := range ins {
+		if ins[i].IdProperty == "" {
+			ins[i].IdProperty = idProperty
+		}
+	}
+
+	return o.batch("upsert", ins)
+}
+
+// Searches objects, one page per call. Use result Paging.NextAfter
+// as req.After to get next page.
+func (o *Objects) Search(req SearchRequest) (res *SearchResult, err error) {
+
+	res = &SearchResult{}
+	if err = o.crm.sendRequest("POST", o.url("search"), req, res); err != nil {
+		res = nil
+	}
+
+	return
+}
+
+// Creates objects (max 100 per call).
+func (o *Objects) BatchCreate(props []Properties) (objs []Object, err error) {
+
+	inputs := []map[string]interface{}{}
+	for _, p := range props {
+		inputs = append(inputs, map[string]interface{}{"properties": p})
+	}
+
+	return o.batch("create", inputs)
+}
+
+// Updates objects by id (max 100 per call).
+func (o *Objects) BatchUpdate(inputs []UpsertInput) (objs []Object, err error) {
+	return o.batch("update", inputs)
+}
+
+// Reads objects by id with given properties (max 100 per call).
+func (o *Objects) BatchRead(ids []string, properties ...string) (objs []Object, err error) {
+
+	inputs := []map[string]string{}
+	for _, id := range ids {
+		inputs = append(inputs, map[string]string{"id": id})
+	}
+
+	var resp struct {
+		Results []Object `json:"results"`
+	}
+	req := map[string]interface{}{"inputs": inputs, "properties": properties}
+	if err = o.crm.sendRequest("POST", o.url("batch/read"), req, &resp); err != nil {
+		return
+	}
+
+	return resp.Results, nil
+}
+
+// Archives objects by id (max 100 per call).
+func (o *Objects) BatchArchive(ids []string) error {
+
+	inputs := []map[string]string{}
+	for _, id := range ids {
+		inputs = append(inputs, map[string]string{"id": id})
+	}
+
+	return o.crm.sendRequest("POST", o.url("batch/archive"), map[string]interface{}{"inputs": inputs}, nil)
+}
+
+// Lists all objects with given properties.
+// Pages are fetched lazily as the iterator advances.
+func (o *Objects) List(properties ...string) *ObjectIter {
+
+	return &ObjectIter{
+		fetch: func(after string) (res *SearchResult, err error) {
+			v := url.Values{}
+			v.Set("limit", "100")
+			if after != "" {
+				v.Set("after", after)
+			}
+			if len(properties) > 0 {
+				v.Set("properties", strings.Join(properties, ","))
+			}
+
+			res = &SearchResult{}
+			err = o.crm.sendRequest("GET", o.url("")+"?"+v.Encode(), nil, res)
+			return
+		},
+	}
+}
+
+// Iterates over all search results starting at req.After.
+func (o *Objects) SearchAll(req SearchRequest) *ObjectIter {
+
+	return &ObjectIter{
+		after: req.After,
+		fetch: func(after string) (*SearchResult, error) {
+			req.After = after
+			return o.Search(req)
+		},
+	}
+}
+
+// Gets contact by email.
+func (c Contacts) GetByEmail(email string, properties ...string) (*Object, error) {
+	return c.GetBy("email", email, properties...)
+}
+
+// Creates or updates contacts matched by email, sent in email order.
+func (c Contacts) UpsertByEmail(contacts map[string]Properties) ([]Object, error) {
+
+	inputs := []UpsertInput{}
+	for email, props := range contacts {
+		inputs = append(inputs, UpsertInput{Id: email, Properties: props})
+	}
+	sort.Slice(inputs, func(i, j int) bool {
+		return inputs[i].Id < inputs[j].Id
+	})
+
+	return c.BatchUpsert("email", inputs)
+}
+
+//------------------------------------------------------------
+// Private methods
+//------------------------------------------------------------
+
+// Sends batch request, returns resulting objects.
+func (o *Objects) batch(action string, inputs interface{}) (objs []Object, err error) {
+
+	var resp struct {
+		Results []Object `json:"results"`
+	}
+	if err = o.crm.sendRequest("POST", o.url("batch/"+action), map[string]interface{}{"inputs": inputs}, &resp); err != nil {
+		return
+	}
+
+	return resp.Results, nil
+}
+
+func (o *Objects) url(path string) string {
+
+	u := fmt.Sprintf(hubObjectsUrl, url.PathEscape(o.objType))
+	if path != "" {
+		u += "/" + path
+	}
+
+	return u
+}
+
+// Sends authenticated request. With Auth set, token is refreshed
+// and request retried once when HubSpot responds 401.
+func (crm *CRM) sendRequest(method, url string, payload, result interface{}) (err error) {
+
+	url = rebase(crm.BaseUrl, hubApiUrl, url)
+
+	if crm.Auth == nil {
+		return sendRequest(crm.HTTPClient, method, url, bearer(crm.Token), payload, result)
+	}
+
+	var token string
+	if token, err = crm.Auth.Token(); err != nil {
+		return
+	}
+
+	err = sendRequest(crm.HTTPClient, method, url, bearer(token), payload, result)
+	if herr, ok := err.(*Error); ok && herr.StatusCode == http.StatusUnauthorized {
+		if token, err = crm.Auth.Refresh(token); err != nil {
+			return
+		}
+		err = sendRequest(crm.HTTPClient, method, url, bearer(token), payload, result)
+	}
+
+	return
+}
+
+func bearer(token string) http.Header {
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+token)
+
+	return header
+}
@@ -0,0 +1,84 @@
+package hubspot
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//------------------------------------------------------------
+// Error
+//------------------------------------------------------------
+
+// Error is a HubSpot API error response, shared by all calls.
+type Error struct {
+	StatusCode    int           `json:"-"`
+	Status        string        `json:"status"`
+	Message       string        `json:"message"`
+	Category      string        `json:"category,omitempty"`
+	CorrelationId string        `json:"correlationId"`
+	Errors        []ErrorDetail `json:"errors,omitempty"`
+}
+
+// Single error entry of an Error.
+type ErrorDetail struct {
+	Message   string              `json:"message"`
+	ErrorType string              `json:"errorType,omitempty"` // forms
+	Code      string              `json:"code,omitempty"`      // crm
+	In        string              `json:"in,omitempty"`
+	Context   map[string][]string `json:"context,omitempty"`
+}
+
+// Matches field names in forms error messages: "Error in 'fields.email'. ..."
+var fieldRe = regexp.MustCompile(`'fields\.([^']+)'`)
+
+func (e *Error) Error() string {
+
+	ss := []string{}
+	for _, d := range e.Errors {
+		ss = append(ss, d.Message)
+	}
+
+	if len(ss) == 0 {
+		return fmt.Sprintf("HubSpot error %d: %s", e.StatusCode, e.Message)
+	}
+
+	return fmt.Sprintf("HubSpot error %d: %s: %s", e.StatusCode, e.Message, strings.Join(ss, "; "))
+}
+
+// Names of the fields or properties that failed validation.
+func (e *Error) Fields() (fields []string) {
+
+	seen := map[string]bool{}
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			fields = append(fields, name)
+		}
+	}
+
+	for _, d := range e.Errors {
+		for _, m := range fieldRe.FindAllStringSubmatch(d.Message, -1) {
+			add(m[1])
+		}
+		for _, name := range d.Context["propertyName"] {
+			add(name)
+		}
+	}
+
+	return
+}
+
+// Builds Error from response body, falling back to raw body
+// as message when it is not HubSpot error JSON.
+func parseError(statusCode int, data []byte) *Error {
+
+	e := &Error{}
+	if err := json.Unmarshal(data, e); err != nil || (e.Message == "" && len(e.Errors) == 0) {
+		e = &Error{Status: "error", Message: strings.TrimSpace(string(data))}
+	}
+	e.StatusCode = statusCode
+
+	return e
+}
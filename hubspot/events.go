@@ -0,0 +1,317 @@
+package hubspot
+
+/*
+
+example:
+
+batch := hubspot.NewEventBatcher(crm, 50)
+
+batch.Add(hubspot.BehavioralEvent{
+    EventName:  "pe1234567_plan_upgraded",
+    Email:      "jo@example.com",
+    Properties: map[string]interface{}{"plan": "pro", "seats": 5},
+})
+...
+err := batch.Flush()
+
+// in tests
+rec := &hubspot.EventRecorder{}
+batch = hubspot.NewEventBatcher(rec, 50)
+
+*/
+
+import (
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------
+// Constants
+//------------------------------------------------------------
+
+const (
+	hubEventsUrl        = hubApiUrl + "/events/v3/send"
+	hubEventsBatchUrl   = hubApiUrl + "/events/v3/send/batch"
+	hubTimelineUrl      = hubApiUrl + "/crm/v3/timeline/events"
+	hubTimelineBatchUrl = hubApiUrl + "/crm/v3/timeline/events/batch/create"
+
+	// API limits per batch call
+	maxEventsBatch   = 500
+	maxTimelineBatch = 100
+)
+
+//------------------------------------------------------------
+// Events model
+//------------------------------------------------------------
+
+// BehavioralEvent is an occurrence of a custom behavioral event.
+// Contact is identified by Email, ObjectId or Utk.
+type BehavioralEvent struct {
+	EventName  string                 `json:"eventName"` // internal name, ie pe1234567_plan_upgraded
+	Email      string                 `json:"email,omitempty"`
+	ObjectId   string                 `json:"objectId,omitempty"`
+	Utk        string                 `json:"utk,omitempty"`
+	OccurredAt time.Time              `json:"occurredAt"` // defaults to now
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// TimelineEvent is an event rendered on contact timeline
+// using an app event template. Contact is identified by
+// Email or ObjectId.
+type TimelineEvent struct {
+	EventTemplateId string                 `json:"eventTemplateId"`
+	Email           string                 `json:"email,omitempty"`
+	ObjectId        string                 `json:"objectId,omitempty"`
+	Timestamp       time.Time              `json:"timestamp"` // defaults to now
+	Tokens          map[string]interface{} `json:"tokens,omitempty"`
+	ExtraData       interface{}            `json:"extraData,omitempty"`
+}
+
+// EventSender delivers events in batches.
+// Implemented by CRM and EventRecorder.
+type EventSender interface {
+	SendEvents(evs []BehavioralEvent) error
+	SendTimelineEvents(evs []TimelineEvent) error
+}
+
+var _ EventSender = (*CRM)(nil)
+
+//------------------------------------------------------------
+// CRM methods: Events
+//------------------------------------------------------------
+
+// Sends single custom behavioral event.
+func (crm *CRM) SendEvent(ev BehavioralEvent) error {
+
+	if ev.OccurredAt.IsZero() {
+		ev.OccurredAt = time.Now()
+	}
+
+	return crm.sendRequest("POST", hubEventsUrl, ev, nil)
+}
+
+// Sends custom behavioral events, split into API sized batches.
+// On error batches before the failed one were already sent.
+func (crm *CRM) SendEvents(evs []BehavioralEvent) (err error) {
+
+	now := time.Now()
+	for start := 0; start < len(evs); start += maxEventsBatch {
+		end := start + maxEventsBatch
+		if end > len(evs) {
+			end = len(evs)
+		}
+
+		inputs := make([]BehavioralEvent, end-start)
+		copy(inputs, evs[start:end])
+		for i := range inputs {
+			if inputs[i].OccurredAt.IsZero() {
+				inputs[i].OccurredAt = now
+			}
+		}
+
+		if err = crm.sendRequest("POST", hubEventsBatchUrl, map[string]interface{}{"inputs": inputs}, nil); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// Sends single timeline event.
+func (crm *CRM) SendTimelineEvent(ev TimelineEvent) error {
+
+	if ev.Timestamp.IsZero() {
+		ev.Timestamp = time.Now()
+	}
+
+	return crm.sendRequest("POST", hubTimelineUrl, ev, nil)
+}
+
+// Sends timeline events, split into API sized batches.
+// On error batches before the failed one were already sent.
+func (crm *CRM) SendTimelineEvents(evs []TimelineEvent) (err error) {
+
+	now := time.Now()
+	for start := 0; start < len(evs); start += maxTimelineBatch {
+		end := start + maxTimelineBatch
+		if end > len(evs) {
+			end = len(evs)
+		}
+
+		inputs := make([]TimelineEvent, end-start)
+		copy(inputs, evs[start:end])
+		for i := range inputs {
+			if inputs[i].Timestamp.IsZero() {
+				inputs[i].Timestamp = now
+			}
+		}
+
+		if err = crm.sendRequest("POST", hubTimelineBatchUrl, map[string]interface{}{"inputs": inputs}, nil); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+//------------------------------------------------------------
+// EventBatcher
+//------------------------------------------------------------
+
+// EventBatcher buffers events and sends them when Size
+// events are pending or on Flush.
+type EventBatcher struct {
+	Sender EventSender
+	Size   int
+
+	mu       sync.Mutex
+	events   []BehavioralEvent
+	timeline []TimelineEvent
+}
+
+func NewEventBatcher(sender EventSender, size int) *EventBatcher {
+	return &EventBatcher{Sender: sender, Size: size}
+}
+
+// Queues behavioral event, flushing when batch is full.
+// Event time is fixed when queued.
+func (b *EventBatcher) Add(ev BehavioralEvent) error {
+
+	if ev.OccurredAt.IsZero() {
+		ev.OccurredAt = time.Now()
+	}
+
+	b.mu.Lock()
+	b.events = append(b.events, ev)
+	full := b.full()
+	b.mu.Unlock()
+
+	if full {
+		return b.Flush()
+	}
+
+	return nil
+}
+
+// Queues timeline event, flushing when batch is full.
+// Event time is fixed when queued.
+func (b *EventBatcher) AddTimeline(ev TimelineEvent) error {
+
+	if ev.Timestamp.IsZero() {
+		ev.Timestamp = time.Now()
+	}
+
+	b.mu.Lock()
+	b.timeline = append(b.timeline, ev)
+	full := b.full()
+	b.mu.Unlock()
+
+	if full {
+		return b.Flush()
+	}
+
+	return nil
+}
+
+// Sends all pending events in API sized batches. Events of the
+// failed batch and the ones after it are put back in front of
+// the queue to be retried on next flush, sent batches are not.
+func (b *EventBatcher) Flush() (err error) {
+
+	b.mu.Lock()
+	events, timeline := b.events, b.timeline
+	b.events, b.timeline = nil, nil
+	b.mu.Unlock()
+
+	for start := 0; start < len(events); start += maxEventsBatch {
+		end := start + maxEventsBatch
+		if end > len(events) {
+			end = len(events)
+		}
+
+		if err = b.Sender.SendEvents(events[start:end]); err != nil {
+			b.requeue(events[start:], timeline)
+			return
+		}
+	}
+
+	for start := 0; start < len(timeline); start += maxTimelineBatch {
+		end := start + maxTimelineBatch
+		if end > len(timeline) {
+			end = len(timeline)
+		}
+
+		if err = b.Sender.SendTimelineEvents(timeline[start:end]); err != nil {
+			b.requeue(nil, timeline[start:])
+			return
+		}
+	}
+
+	return
+}
+
+// Number of events waiting to be sent.
+func (b *EventBatcher) Pending() int {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.events) + len(b.timeline)
+}
+
+func (b *EventBatcher) full() bool {
+	return b.Size > 0 && len(b.events)+len(b.timeline) >= b.Size
+}
+
+func (b *EventBatcher) requeue(events []BehavioralEvent, timeline []TimelineEvent) {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.events = append(append([]BehavioralEvent{}, events...), b.events...)
+	b.timeline = append(append([]TimelineEvent{}, timeline...), b.timeline...)
+}
+
+//------------------------------------------------------------
+// EventRecorder
+//------------------------------------------------------------
+
+// EventRecorder is in-memory EventSender for tests.
+// Events are recorded instead of sent.
+type EventRecorder struct {
+	Events   []BehavioralEvent
+	Timeline []TimelineEvent
+	Batches  int   // number of successful send calls
+	Err      error // returned by sends when set, nothing is recorded
+	ErrAfter int   // sends succeed until Batches reaches it, then Err is returned
+
+	mu sync.Mutex
+}
+
+func (rec *EventRecorder) SendEvents(evs []BehavioralEvent) error {
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if rec.Err != nil && rec.Batches >= rec.ErrAfter {
+		return rec.Err
+	}
+
+	rec.Batches++
+	rec.Events = append(rec.Events, evs...)
+	return nil
+}
+
+func (rec *EventRecorder) SendTimelineEvents(evs []TimelineEvent) error {
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if rec.Err != nil && rec.Batches >= rec.ErrAfter {
+		return rec.Err
+	}
+
+	rec.Batches++
+	rec.Timeline = append(rec.Timeline, evs...)
+	return nil
+}
@@ -0,0 +1,247 @@
+package hubspot
+
+/*
+
+example:
+
+form, err := crm.GetForm(formId)
+...
+if err = hubspot.Validate(form, values); err != nil {
+    verr := err.(*hubspot.ValidationError)
+    fmt.Print(verr.Fields()) // [email firstname]
+    return
+}
+
+err = hubspot.Submit(portalId, formId, values)
+
+*/
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+//------------------------------------------------------------
+// Constants
+//------------------------------------------------------------
+
+const (
+	hubFormDefUrl = hubApiUrl + "/marketing/v3/forms/%s"
+)
+
+//------------------------------------------------------------
+// Form definition model
+//------------------------------------------------------------
+
+// Form is a HubSpot form definition.
+type Form struct {
+	Id          string       `json:"id"`
+	Name        string       `json:"name"`
+	FormType    string       `json:"formType"`
+	Archived    bool         `json:"archived"`
+	FieldGroups []FieldGroup `json:"fieldGroups"`
+}
+
+type FieldGroup struct {
+	GroupType string      `json:"groupType"`
+	Fields    []FormField `json:"fields"`
+}
+
+// FormField is a single field definition.
+// FieldType: email, single_line_text, multi_line_text, number, phone,
+// mobile_phone, dropdown, radio, single_checkbox, multiple_checkboxes,
+// datepicker, file.
+type FormField struct {
+	ObjectTypeId string          `json:"objectTypeId"`
+	Name         string          `json:"name"`
+	Label        string          `json:"label"`
+	FieldType    string          `json:"fieldType"`
+	Required     bool            `json:"required"`
+	Hidden       bool            `json:"hidden"`
+	Options      []FieldOption   `json:"options,omitempty"`
+	Validation   FieldValidation `json:"validation"`
+}
+
+type FieldOption struct {
+	Label        string `json:"label"`
+	Value        string `json:"value"`
+	DisplayOrder int    `json:"displayOrder"`
+}
+
+type FieldValidation struct {
+	BlockedEmailDomains []string `json:"blockedEmailDomains,omitempty"`
+	MinAllowedDigits    int      `json:"minAllowedDigits,omitempty"`
+	MaxAllowedDigits    int      `json:"maxAllowedDigits,omitempty"`
+}
+
+// Error returned by Validate, lists every failing field.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+type FieldError struct {
+	Name    string
+	Message string
+}
+
+//------------------------------------------------------------
+// Methods
+//------------------------------------------------------------
+
+// Retrieves form definition.
+func (crm *CRM) GetForm(formId string) (form *Form, err error) {
+
+	form = &Form{}
+	if err = crm.sendRequest("GET", fmt.Sprintf(hubFormDefUrl, url.PathEscape(formId)), nil, form); err != nil {
+		form = nil
+	}
+
+	return
+}
+
+// All fields of form in display order.
+func (f *Form) Fields() (fields []FormField) {
+
+	for _, g := range f.FieldGroups {
+		fields = append(fields, g.Fields...)
+	}
+
+	return
+}
+
+// Checks values against form definition before submission.
+// Values for fields not in the form are not checked.
+// Returns *ValidationError when any field fails.
+func Validate(form *Form, values map[string]string) error {
+
+	if form == nil {
+		return errors.New("HubSpot validate: no form definition")
+	}
+
+	verr := &ValidationError{}
+	for _, field := range form.Fields() {
+		if msg := field.check(strings.TrimSpace(values[field.Name])); msg != "" {
+			verr.Errors = append(verr.Errors, FieldError{Name: field.Name, Message: msg})
+		}
+	}
+
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+
+	return nil
+}
+
+func (ve *ValidationError) Error() string {
+
+	ss := []string{}
+	for _, fe := range ve.Errors {
+		ss = append(ss, fe.Name+": "+fe.Message)
+	}
+
+	return "HubSpot form validation failed: " + strings.Join(ss, "; ")
+}
+
+// Names of the failing fields.
+func (ve *ValidationError) Fields() (fields []string) {
+
+	for _, fe := range ve.Errors {
+		fields = append(fields, fe.Name)
+	}
+
+	return
+}
+
+//------------------------------------------------------------
+// Private methods
+//------------------------------------------------------------
+
+// Checks single value, returns failure message or empty string.
+func (field *FormField) check(v string) string {
+
+	if v == "" {
+		if field.Required && !field.Hidden {
+			return "required"
+		}
+		return ""
+	}
+
+	switch field.FieldType {
+	case "email":
+		addr, err := mail.ParseAddress(v)
+		if err != nil || addr.Address != v {
+			return "invalid email address"
+		}
+		domain := strings.ToLower(v[strings.LastIndex(v, "@")+1:])
+		for _, blocked := range field.Validation.BlockedEmailDomains {
+			if domain == strings.ToLower(blocked) {
+				return "email domain not allowed"
+			}
+		}
+
+	case "number":
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return "not a number"
+		}
+
+	case "phone", "mobile_phone":
+		n := len(digitsOnly(v))
+		if n == 0 {
+			return "invalid phone number"
+		}
+		if min := field.Validation.MinAllowedDigits; min > 0 && n < min {
+			return fmt.Sprintf("at least %d digits required", min)
+		}
+		if max := field.Validation.MaxAllowedDigits; max > 0 && n > max {
+			return fmt.Sprintf("at most %d digits allowed", max)
+		}
+
+	case "single_checkbox":
+		if v != "true" && v != "false" {
+			return "must be true or false"
+		}
+
+	case "dropdown", "radio":
+		if !field.hasOption(v) {
+			return "invalid option"
+		}
+
+	case "multiple_checkboxes":
+		for _, opt := range strings.Split(v, ";") {
+			if !field.hasOption(strings.TrimSpace(opt)) {
+				return "invalid option " + opt
+			}
+		}
+	}
+
+	return ""
+}
+
+func (field *FormField) hasOption(v string) bool {
+
+	// no options defined means any value
+	if len(field.Options) == 0 {
+		return true
+	}
+
+	for _, opt := range field.Options {
+		if opt.Value == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+func digitsOnly(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < '0' || r > '9' {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,150 @@
+package hubspot
+
+/*
+
+example:
+
+sub := hubspot.Submission{
+    Fields: hubspot.FieldsFromMap(map[string]string{
+        "email":     "jo@example.com",
+        "firstname": "Jo",
+    }),
+    Context: &hubspot.Context{
+        Hutk:     "XXXX",
+        PageUri:  "https://example.com/signup",
+        PageName: "Signup",
+    },
+}
+
+resp, err := hubspot.SubmitV3(portalId, formId, sub)
+if herr, ok := err.(*hubspot.Error); ok {
+    fmt.Print(herr.Fields()) // [email]
+}
+
+*/
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+//------------------------------------------------------------
+// Constants
+//------------------------------------------------------------
+
+const (
+	hubFormsApiUrl = "https://api.hsforms.com"
+	hubFormsV3Url  = hubFormsApiUrl + "/submissions/v3/integration/submit/%s/%s"
+)
+
+//------------------------------------------------------------
+// Forms
+//------------------------------------------------------------
+
+// Forms submits forms via Forms API v3 over custom transport.
+// Zero value is ready to use, see SubmitV3.
+type Forms struct {
+	BaseUrl    string       // optional, replaces https://api.hsforms.com, ie for tests
+	HTTPClient *http.Client // optional, defaults to http.DefaultClient
+}
+
+//------------------------------------------------------------
+// Forms API v3 model
+//------------------------------------------------------------
+
+// Submission is a v3 form submission.
+type Submission struct {
+	SubmittedAt         int64         `json:"submittedAt,omitempty"` // ms since epoch, defaults to now
+	Fields              []Field       `json:"fields"`
+	Context             *Context      `json:"context,omitempty"`
+	LegalConsentOptions *LegalConsent `json:"legalConsentOptions,omitempty"`
+}
+
+// Single form field value.
+type Field struct {
+	ObjectTypeId string `json:"objectTypeId,omitempty"` // "0-1" contact, "0-2" company
+	Name         string `json:"name"`
+	Value        string `json:"value"`
+}
+
+// Context identifies visitor and page the form was submitted from.
+type Context struct {
+	Hutk      string `json:"hutk,omitempty"` // hubspotutk cookie
+	PageUri   string `json:"pageUri,omitempty"`
+	PageName  string `json:"pageName,omitempty"`
+	IpAddress string `json:"ipAddress,omitempty"`
+}
+
+// LegalConsent holds GDPR options. Use either Consent or
+// LegitimateInterest.
+type LegalConsent struct {
+	Consent            *Consent            `json:"consent,omitempty"`
+	LegitimateInterest *LegitimateInterest `json:"legitimateInterest,omitempty"`
+}
+
+type Consent struct {
+	ConsentToProcess bool                   `json:"consentToProcess"`
+	Text             string                 `json:"text"`
+	Communications   []CommunicationConsent `json:"communications,omitempty"`
+}
+
+type CommunicationConsent struct {
+	Value              bool   `json:"value"`
+	SubscriptionTypeId int64  `json:"subscriptionTypeId"`
+	Text               string `json:"text"`
+}
+
+type LegitimateInterest struct {
+	Value              bool   `json:"value"`
+	SubscriptionTypeId int64  `json:"subscriptionTypeId"`
+	LegalBasis         string `json:"legalBasis"` // LEGITIMATE_INTEREST_PQL, LEGITIMATE_INTEREST_CLIENT
+	Text               string `json:"text"`
+}
+
+// Successful submission response.
+type SubmitResponse struct {
+	InlineMessage string `json:"inlineMessage,omitempty"`
+	RedirectUri   string `json:"redirectUri,omitempty"`
+}
+
+//------------------------------------------------------------
+// Methods
+//------------------------------------------------------------
+
+// Submits form via Forms API v3. Validation failures are
+// returned as *Error naming the failing fields.
+func SubmitV3(portalId, formId string, sub Submission) (resp *SubmitResponse, err error) {
+	return (&Forms{}).SubmitV3(portalId, formId, sub)
+}
+
+// Submits form via Forms API v3 using BaseUrl and HTTPClient.
+func (f *Forms) SubmitV3(portalId, formId string, sub Submission) (resp *SubmitResponse, err error) {
+
+	if sub.SubmittedAt == 0 {
+		sub.SubmittedAt = time.Now().UnixNano() / int64(time.Millisecond)
+	}
+
+	resp = &SubmitResponse{}
+	u := rebase(f.BaseUrl, hubFormsApiUrl, fmt.Sprintf(hubFormsV3Url, portalId, formId))
+	if err = sendRequest(f.HTTPClient, "POST", u, nil, sub, resp); err != nil {
+		resp = nil
+	}
+
+	return
+}
+
+// Converts a map to contact fields sorted by name.
+func FieldsFromMap(m map[string]string) (fields []Field) {
+
+	for k, v := range m {
+		fields = append(fields, Field{Name: k, Value: v})
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Name < fields[j].Name
+	})
+
+	return
+}
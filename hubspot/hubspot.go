@@ -31,6 +31,7 @@ func Submit(portalId, formId string, form map[string]string) (err error) {
     if err != nil {
         return
     }
+    defer resp.Body.Close()
 
     if resp.StatusCode != 204 {
         err = fmt.Errorf("Error submitting HubSpot form to %s. StatusCode: %d, expected 204", url, resp.StatusCode)
@@ -40,25 +41,19 @@ func Submit(portalId, formId string, form map[string]string) (err error) {
 }
 
 // Convenience function to make a proper HubSpot request.
-// hubspotuk is taken from the request cookies
+// hubspotuk is taken from the request cookies, if present.
 // The resulting map should be filled with the other parameters
-// for the form.
+// for the form. See NewContext for proxy aware context.
 func Build(pageName string, r *http.Request) (m map[string]string) {
 
-    m = map[string]string{}
+    ctx := NewContext(r, ContextOptions{PageName: pageName})
 
-    // get context cookie
-    hubspotutk, err := r.Cookie("hubspotutk")
-    if err != nil {
-        return
-    }
-
-    // build context
+    // build legacy context
     hubCtx := map[string]string{
-        "hutk":      hubspotutk.Value,
-        "ipAddress": r.RemoteAddr,
-        "pageUrl":   r.URL.Host + r.URL.Path,
-        "pageName":  pageName,
+        "hutk":      ctx.Hutk,
+        "ipAddress": ctx.IpAddress,
+        "pageUrl":   ctx.PageUri,
+        "pageName":  ctx.PageName,
     }
 
     // encode context to json
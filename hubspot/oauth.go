@@ -0,0 +1,325 @@
+package hubspot
+
+// Flow:
+// 1. Redirect user to AuthUri, they install the app on their portal. This produces a code
+// 2. The code is exchanged for access and refresh tokens with ExchangeCode
+// 3. CRM built with NewCRMOAuth uses stored tokens, refreshing them when expired or on 401
+
+/*
+
+example:
+
+oa := &hubspot.OAuth{
+    ClientId:     "XXXX",
+    ClientSecret: "XXXX",
+    Redirect:     "https://example.com/hubspot/callback",
+    State:        "XXXX",
+}
+
+http.Redirect(w, r, oa.AuthUri("crm.objects.contacts.write"), http.StatusFound)
+
+// callback
+tok, err := oa.ExchangeCode(r.FormValue("code"))
+info, err := oa.Introspect(tok.AccessToken)
+err = oa.SaveToken(strconv.FormatInt(info.HubId, 10), tok)
+
+// later, acting on behalf of portal
+crm := hubspot.NewCRMOAuth(oa, portalId)
+
+*/
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------
+// Constants
+//------------------------------------------------------------
+
+const (
+	hubAuthUrl        = "https://app.hubspot.com/oauth/authorize?"
+	hubTokenUrl       = hubApiUrl + "/oauth/v1/token"
+	hubTokenInfoUrl   = hubApiUrl + "/oauth/v1/access-tokens/%s"
+	hubRefreshInfoUrl = hubApiUrl + "/oauth/v1/refresh-tokens/%s"
+
+	// refresh this long before access token expires
+	tokenExpiryLeeway = time.Minute
+)
+
+//------------------------------------------------------------
+// OAuth
+//------------------------------------------------------------
+
+// Contains data for the HubSpot OAuth app.
+type OAuth struct {
+	ClientId     string
+	ClientSecret string
+	Redirect     string
+	State        string
+
+	Store      TokenStore   // in-memory when nil
+	BaseUrl    string       // optional, replaces https://api.hubapi.com, ie for tests
+	HTTPClient *http.Client // optional, defaults to http.DefaultClient
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex // per token key, guarded by mu
+	once  sync.Once
+}
+
+// Token issued by HubSpot for one portal.
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	TokenType    string    `json:"token_type"`
+	ExpiresIn    int       `json:"expires_in"` // seconds, as issued
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// Tells if access token is expired or about to expire.
+func (t *Token) Expired() bool {
+	return !t.ExpiresAt.IsZero() && time.Now().Add(tokenExpiryLeeway).After(t.ExpiresAt)
+}
+
+// Access token metadata.
+type TokenInfo struct {
+	Token     string   `json:"token"`
+	User      string   `json:"user"`
+	UserId    int64    `json:"user_id"`
+	HubDomain string   `json:"hub_domain"`
+	HubId     int64    `json:"hub_id"`
+	AppId     int64    `json:"app_id"`
+	Scopes    []string `json:"scopes"`
+	ExpiresIn int      `json:"expires_in"`
+	TokenType string   `json:"token_type"`
+}
+
+// Generate an AuthUri - the user must be redirected here and install
+// the app. This produces a code which must be sent to ExchangeCode.
+func (oa *OAuth) AuthUri(scope ...string) string {
+
+	v := url.Values{}
+	v.Add("client_id", oa.ClientId)
+	v.Add("redirect_uri", oa.Redirect)
+	v.Add("scope", strings.Join(scope, " "))
+	if oa.State != "" {
+		v.Add("state", oa.State)
+	}
+
+	return fmt.Sprint(hubAuthUrl, v.Encode())
+}
+
+// Exchanges authorization code for tokens.
+func (oa *OAuth) ExchangeCode(code string) (tok *Token, err error) {
+
+	return oa.requestToken(url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {oa.Redirect},
+	})
+}
+
+// Gets new access token using refresh token.
+func (oa *OAuth) Refresh(refreshToken string) (tok *Token, err error) {
+
+	return oa.requestToken(url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+}
+
+// Gets access token metadata: portal, user, scopes.
+func (oa *OAuth) Introspect(accessToken string) (info *TokenInfo, err error) {
+
+	info = &TokenInfo{}
+	if err = sendRequest(oa.HTTPClient, "GET", rebase(oa.BaseUrl, hubApiUrl, fmt.Sprintf(hubTokenInfoUrl, url.PathEscape(accessToken))), nil, nil, info); err != nil {
+		info = nil
+	}
+
+	return
+}
+
+// Revokes refresh token, ie when app is uninstalled.
+func (oa *OAuth) Revoke(refreshToken string) error {
+	return sendRequest(oa.HTTPClient, "DELETE", rebase(oa.BaseUrl, hubApiUrl, fmt.Sprintf(hubRefreshInfoUrl, url.PathEscape(refreshToken))), nil, nil, nil)
+}
+
+// Saves portal token under key for NewCRMOAuth.
+func (oa *OAuth) SaveToken(key string, tok *Token) error {
+	return oa.store().SaveToken(key, tok)
+}
+
+// CRM client acting on behalf of portal whose token is stored under key.
+func NewCRMOAuth(oa *OAuth, key string) *CRM {
+	return &CRM{Auth: &oauthSource{oa: oa, key: key}, BaseUrl: oa.BaseUrl, HTTPClient: oa.HTTPClient}
+}
+
+//------------------------------------------------------------
+// TokenStore
+//------------------------------------------------------------
+
+// TokenStore keeps tokens per portal, key is up to the caller,
+// ie portal id.
+type TokenStore interface {
+	// Returns token or nil if there is none.
+	GetToken(key string) (*Token, error)
+	SaveToken(key string, tok *Token) error
+}
+
+// MemoryTokenStore is process local TokenStore.
+type MemoryTokenStore struct {
+	mu     sync.RWMutex
+	tokens map[string]Token
+}
+
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{tokens: map[string]Token{}}
+}
+
+func (ms *MemoryTokenStore) GetToken(key string) (*Token, error) {
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	if tok, ok := ms.tokens[key]; ok {
+		return &tok, nil
+	}
+
+	return nil, nil
+}
+
+func (ms *MemoryTokenStore) SaveToken(key string, tok *Token) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.tokens[key] = *tok
+	return nil
+}
+
+//------------------------------------------------------------
+// Authorizer
+//------------------------------------------------------------
+
+// Authorizer provides access tokens to CRM.
+// Refresh is called once when HubSpot responds 401 with the
+// rejected token, implementations may return a token refreshed
+// meanwhile instead of refreshing again.
+type Authorizer interface {
+	Token() (string, error)
+	Refresh(rejected string) (string, error)
+}
+
+// Authorizer backed by OAuth token store.
+type oauthSource struct {
+	oa  *OAuth
+	key string
+}
+
+func (s *oauthSource) Token() (string, error) {
+
+	tok, err := s.oa.storedToken(s.key)
+	if err != nil {
+		return "", err
+	}
+
+	if tok.Expired() {
+		return s.Refresh(tok.AccessToken)
+	}
+
+	return tok.AccessToken, nil
+}
+
+// Refreshes token unless concurrent caller already replaced
+// the rejected one. Portals refresh independently.
+func (s *oauthSource) Refresh(rejected string) (string, error) {
+
+	lock := s.oa.keyLock(s.key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	tok, err := s.oa.storedToken(s.key)
+	if err != nil {
+		return "", err
+	}
+
+	if tok.AccessToken != rejected && !tok.Expired() {
+		return tok.AccessToken, nil
+	}
+
+	var fresh *Token
+	if fresh, err = s.oa.Refresh(tok.RefreshToken); err != nil {
+		return "", err
+	}
+	if fresh.RefreshToken == "" {
+		fresh.RefreshToken = tok.RefreshToken
+	}
+	tok = fresh
+
+	if err = s.oa.store().SaveToken(s.key, tok); err != nil {
+		return "", err
+	}
+
+	return tok.AccessToken, nil
+}
+
+//------------------------------------------------------------
+// Private methods
+//------------------------------------------------------------
+
+func (oa *OAuth) requestToken(form url.Values) (tok *Token, err error) {
+
+	form.Set("client_id", oa.ClientId)
+	form.Set("client_secret", oa.ClientSecret)
+
+	tok = &Token{}
+	if err = postForm(oa.HTTPClient, rebase(oa.BaseUrl, hubApiUrl, hubTokenUrl), form, tok); err != nil {
+		return nil, err
+	}
+
+	if tok.ExpiresIn > 0 {
+		tok.ExpiresAt = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	}
+
+	return
+}
+
+// Lock serializing refreshes of token stored under key.
+func (oa *OAuth) keyLock(key string) *sync.Mutex {
+
+	oa.mu.Lock()
+	defer oa.mu.Unlock()
+
+	if oa.locks == nil {
+		oa.locks = map[string]*sync.Mutex{}
+	}
+	if oa.locks[key] == nil {
+		oa.locks[key] = &sync.Mutex{}
+	}
+
+	return oa.locks[key]
+}
+
+func (oa *OAuth) store() TokenStore {
+
+	oa.once.Do(func() {
+		if oa.Store == nil {
+			oa.Store = NewMemoryTokenStore()
+		}
+	})
+
+	return oa.Store
+}
+
+func (oa *OAuth) storedToken(key string) (tok *Token, err error) {
+
+	if tok, err = oa.store().GetToken(key); err == nil && tok == nil {
+		err = fmt.Errorf("HubSpot OAuth: no token for %s", key)
+	}
+
+	return
+}
@@ -0,0 +1,57 @@
+package hubspot
+
+//------------------------------------------------------------
+// Paging
+//------------------------------------------------------------
+
+// ObjectIter iterates over CRM objects across all result pages
+// following "after" cursors.
+//
+//	it := crm.Deals().List("dealname", "amount")
+//	for it.Next() {
+//	    deal := it.Object()
+//	}
+//	if err := it.Err(); err != nil {
+//	    ...
+//	}
+type ObjectIter struct {
+	fetch func(after string) (*SearchResult, error)
+	after string
+	items []Object
+	cur   Object
+	done  bool
+	err   error
+}
+
+// Advances to the next object, fetching next page when needed.
+// Returns false when done or on error, see Err.
+func (it *ObjectIter) Next() bool {
+
+	for len(it.items) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+
+		var res *SearchResult
+		if res, it.err = it.fetch(it.after); it.err != nil {
+			return false
+		}
+
+		it.items = res.Results
+		it.after = res.Paging.NextAfter()
+		it.done = it.after == ""
+	}
+
+	it.cur, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// Current object.
+func (it *ObjectIter) Object() Object {
+	return it.cur
+}
+
+// First error encountered while fetching pages, if any.
+func (it *ObjectIter) Err() error {
+	return it.err
+}
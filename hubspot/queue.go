@@ -0,0 +1,534 @@
+package hubspot
+
+/*
+
+example:
+
+store, err := hubspot.NewFileQueueStore("/var/lib/app/hubspot_queue.log")
+...
+sub := hubspot.NewSubmitter(store)
+sub.Start()
+defer sub.Stop()
+
+// in request handler, returns immediately
+form := hubspot.Build("Signup", r)
+form["email"] = email
+err = sub.Enqueue(signupId, portalId, formId, form)
+
+*/
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------
+// Constants
+//------------------------------------------------------------
+
+// Submitter poll interval when not set.
+const defaultPollInterval = 5 * time.Second
+
+//------------------------------------------------------------
+// Queue model
+//------------------------------------------------------------
+
+// QueuedSubmission is a form submission waiting to be sent.
+type QueuedSubmission struct {
+	Key         string            `json:"key"` // idempotency key
+	PortalId    string            `json:"portalId"`
+	FormId      string            `json:"formId"`
+	Form        map[string]string `json:"form"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	Attempts    int               `json:"attempts"`
+	NextAttempt time.Time         `json:"nextAttempt"`
+	LastError   string            `json:"lastError,omitempty"`
+	Failed      bool              `json:"failed"` // gave up after MaxAttempts
+	SentAt      time.Time         `json:"sentAt,omitempty"`
+}
+
+// Tells if submission is a tombstone of a sent one, kept
+// for DedupWindow so its key is not queued again.
+func (sub QueuedSubmission) Sent() bool {
+	return !sub.SentAt.IsZero()
+}
+
+// QueueStore persists queued submissions by key,
+// including tombstones of sent ones.
+type QueueStore interface {
+	Put(sub QueuedSubmission) error
+	Get(key string) (sub QueuedSubmission, ok bool, err error)
+	Delete(key string) error
+	All() ([]QueuedSubmission, error)
+}
+
+// Submitter counters.
+type SubmitterStats struct {
+	Pending   int // waiting for first or next attempt
+	Failed    int // gave up, kept in store
+	Succeeded int // since start
+	Retries   int // failed attempts since start
+}
+
+//------------------------------------------------------------
+// Submitter
+//------------------------------------------------------------
+
+// Submitter sends form submissions in the background,
+// retrying failures with exponential backoff. HubSpot 4xx
+// responses other than 429 fail submission without retry.
+type Submitter struct {
+	Store        QueueStore
+	MaxAttempts  int
+	MinBackoff   time.Duration
+	MaxBackoff   time.Duration
+	PollInterval time.Duration
+	DedupWindow  time.Duration // sent keys are remembered this long, in store
+
+	// Sends submission, Submit by default but failing with *Error.
+	SubmitFunc func(portalId, formId string, form map[string]string) error
+
+	// Called with errors of background processing, optional.
+	OnError func(err error)
+
+	mu        sync.Mutex
+	pmu       sync.Mutex // serializes Process
+	succeeded int
+	retries   int
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+func NewSubmitter(store QueueStore) *Submitter {
+	return &Submitter{
+		Store:        store,
+		MaxAttempts:  10,
+		MinBackoff:   10 * time.Second,
+		MaxBackoff:   time.Hour,
+		PollInterval: defaultPollInterval,
+		DedupWindow:  24 * time.Hour,
+		SubmitFunc:   submitForm,
+	}
+}
+
+// Queues submission. Submissions with key already queued or
+// sent within DedupWindow are ignored, across restarts when
+// store is persistent.
+func (s *Submitter) Enqueue(key, portalId, formId string, form map[string]string) (err error) {
+
+	if key == "" {
+		return errors.New("HubSpot submitter: empty idempotency key")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	var sub QueuedSubmission
+	var ok bool
+	if sub, ok, err = s.Store.Get(key); err != nil {
+		return
+	}
+	if ok && !s.expired(sub, now) {
+		return
+	}
+
+	return s.Store.Put(QueuedSubmission{
+		Key:         key,
+		PortalId:    portalId,
+		FormId:      formId,
+		Form:        form,
+		CreatedAt:   now,
+		NextAttempt: now,
+	})
+}
+
+// Starts background processing.
+func (s *Submitter) Start() {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop != nil {
+		return
+	}
+	s.stop = make(chan struct{})
+
+	s.wg.Add(1)
+	go func(stop chan struct{}) {
+		defer s.wg.Done()
+
+		interval := s.PollInterval
+		if interval <= 0 {
+			interval = defaultPollInterval
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.Process(); err != nil && s.OnError != nil {
+				s.OnError(err)
+			}
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}(s.stop)
+}
+
+// Stops background processing, waiting for the current pass to finish.
+// Pending submissions stay in store.
+func (s *Submitter) Stop() {
+
+	s.mu.Lock()
+	stop := s.stop
+	s.stop = nil
+	s.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		s.wg.Wait()
+	}
+}
+
+// Sends all due submissions once and drops tombstones older than
+// DedupWindow. Called periodically after Start, can be called
+// directly for synchronous processing.
+func (s *Submitter) Process() (err error) {
+
+	s.pmu.Lock()
+	defer s.pmu.Unlock()
+
+	var subs []QueuedSubmission
+	if subs, err = s.Store.All(); err != nil {
+		return
+	}
+
+	now := time.Now()
+	for _, sub := range subs {
+		if s.expired(sub, now) {
+			s.mu.Lock()
+			err = s.Store.Delete(sub.Key)
+			s.mu.Unlock()
+			if err != nil {
+				return
+			}
+			continue
+		}
+		if sub.Sent() || sub.Failed || sub.NextAttempt.After(now) {
+			continue
+		}
+
+		sendErr := s.SubmitFunc(sub.PortalId, sub.FormId, sub.Form)
+
+		s.mu.Lock()
+		if sendErr == nil {
+			s.succeeded++
+			err = s.Store.Put(QueuedSubmission{Key: sub.Key, CreatedAt: sub.CreatedAt, SentAt: now})
+		} else {
+			s.retries++
+			sub.Attempts++
+			sub.LastError = sendErr.Error()
+			sub.NextAttempt = now.Add(s.backoff(sub.Attempts))
+			sub.Failed = permanent(sendErr) || s.MaxAttempts > 0 && sub.Attempts >= s.MaxAttempts
+			err = s.Store.Put(sub)
+		}
+		s.mu.Unlock()
+
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// Re-queues failed submissions for immediate retry.
+func (s *Submitter) RetryFailed() (err error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var subs []QueuedSubmission
+	if subs, err = s.Store.All(); err != nil {
+		return
+	}
+
+	for _, sub := range subs {
+		if sub.Failed {
+			sub.Failed, sub.Attempts, sub.NextAttempt = false, 0, time.Now()
+			if err = s.Store.Put(sub); err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// Current counters.
+func (s *Submitter) Stats() (stats SubmitterStats, err error) {
+
+	var subs []QueuedSubmission
+	if subs, err = s.Store.All(); err != nil {
+		return
+	}
+
+	for _, sub := range subs {
+		switch {
+		case sub.Sent():
+		case sub.Failed:
+			stats.Failed++
+		default:
+			stats.Pending++
+		}
+	}
+
+	s.mu.Lock()
+	stats.Succeeded, stats.Retries = s.succeeded, s.retries
+	s.mu.Unlock()
+
+	return
+}
+
+// Exponential backoff capped at MaxBackoff.
+func (s *Submitter) backoff(attempts int) time.Duration {
+
+	d := s.MinBackoff
+	for i := 1; i < attempts && d < s.MaxBackoff; i++ {
+		d *= 2
+	}
+	if s.MaxBackoff > 0 && d > s.MaxBackoff {
+		d = s.MaxBackoff
+	}
+
+	return d
+}
+
+// Tells if HubSpot rejected submission itself, retrying will not help.
+func permanent(err error) bool {
+
+	var herr *Error
+	if !errors.As(err, &herr) {
+		return false
+	}
+
+	return herr.StatusCode >= 400 && herr.StatusCode < 500 && herr.StatusCode != http.StatusTooManyRequests
+}
+
+// Default SubmitFunc: Submit with failures returned as *Error
+// so permanent ones are not retried.
+func submitForm(portalId, formId string, form map[string]string) error {
+	return postForm(nil, buildFormsUrl(portalId, formId), toValues(form), nil)
+}
+
+// Tells if sent submission tombstone is past DedupWindow.
+func (s *Submitter) expired(sub QueuedSubmission, now time.Time) bool {
+	return sub.Sent() && now.Sub(sub.SentAt) > s.DedupWindow
+}
+
+//------------------------------------------------------------
+// MemoryQueueStore
+//------------------------------------------------------------
+
+// MemoryQueueStore is process local QueueStore.
+type MemoryQueueStore struct {
+	mu   sync.Mutex
+	subs map[string]QueuedSubmission
+}
+
+func NewMemoryQueueStore() *MemoryQueueStore {
+	return &MemoryQueueStore{subs: map[string]QueuedSubmission{}}
+}
+
+func (ms *MemoryQueueStore) Put(sub QueuedSubmission) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.subs[sub.Key] = sub
+	return nil
+}
+
+func (ms *MemoryQueueStore) Get(key string) (QueuedSubmission, bool, error) {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	sub, ok := ms.subs[key]
+	return sub, ok, nil
+}
+
+func (ms *MemoryQueueStore) Delete(key string) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.subs, key)
+	return nil
+}
+
+// All submissions, oldest first.
+func (ms *MemoryQueueStore) All() ([]QueuedSubmission, error) {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	subs := make([]QueuedSubmission, 0, len(ms.subs))
+	for _, sub := range ms.subs {
+		subs = append(subs, sub)
+	}
+
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].CreatedAt.Before(subs[j].CreatedAt)
+	})
+
+	return subs, nil
+}
+
+//------------------------------------------------------------
+// FileQueueStore
+//------------------------------------------------------------
+
+// FileQueueStore is QueueStore persisted as append-only log of
+// JSON lines, replayed on open. Use Compact to drop history,
+// including tombstones Submitter has expired.
+type FileQueueStore struct {
+	mem  *MemoryQueueStore
+	path string
+	mu   sync.Mutex
+}
+
+// Single log line.
+type queueLogEntry struct {
+	Op  string            `json:"op"` // put, del
+	Key string            `json:"key,omitempty"`
+	Sub *QueuedSubmission `json:"sub,omitempty"`
+}
+
+// Opens log file, creating it if missing, and replays it.
+func NewFileQueueStore(path string) (fs *FileQueueStore, err error) {
+
+	var f *os.File
+	if f, err = os.OpenFile(path, os.O_RDONLY|os.O_CREATE, 0644); err != nil {
+		return
+	}
+	defer f.Close()
+
+	fs = &FileQueueStore{mem: NewMemoryQueueStore(), path: path}
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		var e queueLogEntry
+		if json.Unmarshal(sc.Bytes(), &e) != nil {
+			// torn last write
+			continue
+		}
+		switch {
+		case e.Op == "put" && e.Sub != nil:
+			fs.mem.Put(*e.Sub)
+		case e.Op == "del":
+			fs.mem.Delete(e.Key)
+		}
+	}
+
+	if err = sc.Err(); err != nil {
+		fs = nil
+	}
+
+	return
+}
+
+func (fs *FileQueueStore) Put(sub QueuedSubmission) (err error) {
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err = fs.append(queueLogEntry{Op: "put", Sub: &sub}); err != nil {
+		return
+	}
+
+	return fs.mem.Put(sub)
+}
+
+func (fs *FileQueueStore) Delete(key string) (err error) {
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err = fs.append(queueLogEntry{Op: "del", Key: key}); err != nil {
+		return
+	}
+
+	return fs.mem.Delete(key)
+}
+
+func (fs *FileQueueStore) Get(key string) (QueuedSubmission, bool, error) {
+	return fs.mem.Get(key)
+}
+
+func (fs *FileQueueStore) All() ([]QueuedSubmission, error) {
+	return fs.mem.All()
+}
+
+// Rewrites log keeping only current submissions and tombstones.
+func (fs *FileQueueStore) Compact() (err error) {
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	subs, _ := fs.mem.All()
+
+	tmp := fs.path + ".tmp"
+	var f *os.File
+	if f, err = os.Create(tmp); err != nil {
+		return
+	}
+
+	enc := json.NewEncoder(f)
+	for i := range subs {
+		if err = enc.Encode(queueLogEntry{Op: "put", Sub: &subs[i]}); err != nil {
+			f.Close()
+			return
+		}
+	}
+
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+
+	return os.Rename(tmp, fs.path)
+}
+
+func (fs *FileQueueStore) append(e queueLogEntry) (err error) {
+
+	var f *os.File
+	if f, err = os.OpenFile(fs.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644); err != nil {
+		return
+	}
+
+	if err = json.NewEncoder(f).Encode(e); err == nil {
+		err = f.Sync()
+	}
+
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	return
+}
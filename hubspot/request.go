@@ -0,0 +1,109 @@
+package hubspot
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+//------------------------------------------------------------
+// Private methods
+//------------------------------------------------------------
+
+// Sends JSON request to HubSpot and decodes JSON response into result.
+// payload and result may be nil. Responses >= 400 are returned as *Error.
+// Client may be nil to use http.DefaultClient.
+func sendRequest(client *http.Client, method, url string, header http.Header, payload, result interface{}) (err error) {
+
+	var req *http.Request
+
+	if payload != nil {
+		// With JSON payload
+		var buf bytes.Buffer
+
+		if err = json.NewEncoder(&buf).Encode(payload); err != nil {
+			return
+		}
+
+		if req, err = http.NewRequest(method, url, &buf); err != nil {
+			return
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+
+	} else {
+		// Without JSON payload
+		if req, err = http.NewRequest(method, url, nil); err != nil {
+			return
+		}
+	}
+
+	for k, vs := range header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	req.Header.Set("Accept", "application/json")
+
+	return doRequest(client, req, result)
+}
+
+// Posts url-encoded form to HubSpot and decodes JSON response into result.
+func postForm(client *http.Client, apiUrl string, form url.Values, result interface{}) (err error) {
+
+	var req *http.Request
+	if req, err = http.NewRequest("POST", apiUrl, strings.NewReader(form.Encode())); err != nil {
+		return
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	return doRequest(client, req, result)
+}
+
+// Sends request, responses >= 400 are returned as *Error.
+func doRequest(client *http.Client, req *http.Request, result interface{}) (err error) {
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var resp *http.Response
+
+	// Send request
+	if resp, err = client.Do(req); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	// Read response
+	var data []byte
+	if data, err = ioutil.ReadAll(resp.Body); err != nil {
+		return
+	}
+
+	// Error returned?
+	if resp.StatusCode >= 400 {
+		return parseError(resp.StatusCode, data)
+	}
+
+	if result != nil && len(data) > 0 {
+		err = json.Unmarshal(data, result)
+	}
+
+	return
+}
+
+// Replaces host prefix of apiUrl with base when set, ie for tests.
+func rebase(base, host, apiUrl string) string {
+
+	if base == "" || !strings.HasPrefix(apiUrl, host) {
+		return apiUrl
+	}
+
+	return base + strings.TrimPrefix(apiUrl, host)
+}
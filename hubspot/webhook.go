@@ -0,0 +1,202 @@
+package hubspot
+
+/*
+
+example:
+
+wh := hubspot.NewWebhookHandler("CLIENT_SECRET")
+wh.On("contact.propertyChange", func(ev hubspot.WebhookEvent) error {
+    fmt.Print(ev.ObjectId, ev.PropertyName, ev.PropertyValue)
+    return nil
+})
+
+http.Handle("/hubspot/webhook", wh)
+
+*/
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------
+// Webhook model
+//------------------------------------------------------------
+
+// WebhookEvent is a single notification of a webhook batch.
+type WebhookEvent struct {
+	EventId          int64  `json:"eventId"`
+	SubscriptionId   int64  `json:"subscriptionId"`
+	SubscriptionType string `json:"subscriptionType"` // ie contact.propertyChange
+	PortalId         int64  `json:"portalId"`
+	AppId            int64  `json:"appId"`
+	OccurredAt       int64  `json:"occurredAt"` // ms since epoch
+	AttemptNumber    int    `json:"attemptNumber"`
+	ObjectId         int64  `json:"objectId"`
+	ChangeSource     string `json:"changeSource"`
+	ChangeFlag       string `json:"changeFlag,omitempty"`
+	PropertyName     string `json:"propertyName,omitempty"`
+	PropertyValue    string `json:"propertyValue,omitempty"`
+
+	// merge, restore and association events
+	MergedObjectIds    []int64 `json:"mergedObjectIds,omitempty"`
+	PrimaryObjectId    int64   `json:"primaryObjectId,omitempty"`
+	AssociationType    string  `json:"associationType,omitempty"`
+	FromObjectId       int64   `json:"fromObjectId,omitempty"`
+	ToObjectId         int64   `json:"toObjectId,omitempty"`
+	AssociationRemoved bool    `json:"associationRemoved,omitempty"`
+}
+
+// Time the event occurred.
+func (ev WebhookEvent) Time() time.Time {
+	return time.Unix(0, ev.OccurredAt*int64(time.Millisecond))
+}
+
+// Handles events of one subscription type. Returning error
+// responds 500 and HubSpot retries the whole batch.
+type WebhookFunc func(ev WebhookEvent) error
+
+//------------------------------------------------------------
+// WebhookHandler
+//------------------------------------------------------------
+
+// WebhookHandler is http.Handler receiving HubSpot webhook batches
+// signed with X-HubSpot-Signature-v3.
+type WebhookHandler struct {
+	ClientSecret string        // app client secret
+	MaxAge       time.Duration // replay window, 5 minutes by default
+
+	// Public url of the webhook as configured in HubSpot, when
+	// requests are proxied. Otherwise reconstructed from request,
+	// see ContextOptions.TrustedProxies.
+	Uri            string
+	TrustedProxies []string
+
+	mu       sync.RWMutex
+	handlers map[string]WebhookFunc
+}
+
+func NewWebhookHandler(clientSecret string) *WebhookHandler {
+	return &WebhookHandler{
+		ClientSecret: clientSecret,
+		MaxAge:       5 * time.Minute,
+		handlers:     map[string]WebhookFunc{},
+	}
+}
+
+// Registers callback for subscription type, ie "contact.creation".
+// Events of types without callback are acknowledged and dropped.
+func (wh *WebhookHandler) On(subscriptionType string, fn WebhookFunc) {
+
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+
+	if wh.handlers == nil {
+		wh.handlers = map[string]WebhookFunc{}
+	}
+	wh.handlers[subscriptionType] = fn
+}
+
+func (wh *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "cannot read body", http.StatusBadRequest)
+		return
+	}
+
+	if !wh.validSignature(r, body) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var events []WebhookEvent
+	if err = json.Unmarshal(body, &events); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	wh.mu.RLock()
+	defer wh.mu.RUnlock()
+
+	for _, ev := range events {
+		if fn := wh.handlers[ev.SubscriptionType]; fn != nil {
+			if err = fn(ev); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//------------------------------------------------------------
+// Signature
+//------------------------------------------------------------
+
+// Computes v3 signature: base64 HMAC-SHA256 of
+// method + uri + body + timestamp keyed with client secret.
+func SignatureV3(clientSecret, method, uri string, body []byte, timestamp string) string {
+
+	mac := hmac.New(sha256.New, []byte(clientSecret))
+	mac.Write([]byte(method + decodeUri(uri)))
+	mac.Write(body)
+	mac.Write([]byte(timestamp))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Checks signature and that timestamp is within replay window.
+// Without ClientSecret every request is rejected.
+func (wh *WebhookHandler) validSignature(r *http.Request, body []byte) bool {
+
+	sig := r.Header.Get("X-HubSpot-Signature-v3")
+	ts := r.Header.Get("X-HubSpot-Request-Timestamp")
+	if wh.ClientSecret == "" || sig == "" || ts == "" {
+		return false
+	}
+
+	ms, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	maxAge := wh.MaxAge
+	if maxAge <= 0 {
+		maxAge = 5 * time.Minute
+	}
+	age := time.Since(time.Unix(0, ms*int64(time.Millisecond)))
+	if age > maxAge || age < -maxAge {
+		return false
+	}
+
+	uri := wh.Uri
+	if uri == "" {
+		uri = pageUri(r, parseNets(wh.TrustedProxies))
+	} else if r.URL.RawQuery != "" && !strings.Contains(uri, "?") {
+		uri += "?" + r.URL.RawQuery
+	}
+
+	expected := SignatureV3(wh.ClientSecret, r.Method, uri, body, ts)
+	return hmac.Equal([]byte(expected), []byte(sig))
+}
+
+// HubSpot signs uri with these characters decoded.
+var uriDecoder = strings.NewReplacer(
+	"%3A", ":", "%2F", "/", "%3F", "?", "%40", "@", "%21", "!",
+	"%24", "$", "%27", "'", "%28", "(", "%29", ")", "%2A", "*",
+	"%2C", ",", "%3B", ";",
+)
+
+func decodeUri(uri string) string {
+	return uriDecoder.Replace(uri)
+}
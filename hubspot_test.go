@@ -0,0 +1,681 @@
+package alienplugs
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deze333/alienplugs/hubspot"
+)
+
+func TestHubSpotErrorFields(t *testing.T) {
+
+	herr := &hubspot.Error{
+		StatusCode: 400,
+		Message:    "The request is not valid",
+		Errors: []hubspot.ErrorDetail{
+			{Message: "Error in 'fields.email'. Invalid email address", ErrorType: "INVALID_EMAIL"},
+			{Message: "Required field 'fields.firstname' is missing", ErrorType: "REQUIRED_FIELD"},
+			{Message: "Property values were not valid", Context: map[string][]string{"propertyName": {"email"}}},
+		},
+	}
+
+	if fields := herr.Fields(); !reflect.DeepEqual(fields, []string{"email", "firstname"}) {
+		t.Fatalf("Unexpected failing fields: %v", fields)
+	}
+}
+
+func TestHubSpotSubmitV3(t *testing.T) {
+
+	var sub hubspot.Submission
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/submissions/v3/integration/submit/P1/F1" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&sub)
+		if sub.Fields[0].Value == "bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status":"error","message":"The request is not valid","errors":[{"message":"Error in 'fields.email'. Invalid email address","errorType":"INVALID_EMAIL"}]}`))
+			return
+		}
+		w.Write([]byte(`{"inlineMessage":"Thanks"}`))
+	}))
+	defer srv.Close()
+
+	forms := &hubspot.Forms{BaseUrl: srv.URL, HTTPClient: srv.Client()}
+
+	resp, err := forms.SubmitV3("P1", "F1", hubspot.Submission{Fields: hubspot.FieldsFromMap(map[string]string{"email": "jo@example.com"})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.InlineMessage != "Thanks" || sub.SubmittedAt == 0 {
+		t.Fatalf("Unexpected response %+v, submission %+v", resp, sub)
+	}
+
+	// Validation failure
+	resp, err = forms.SubmitV3("P1", "F1", hubspot.Submission{Fields: hubspot.FieldsFromMap(map[string]string{"email": "bad"})})
+	herr, ok := err.(*hubspot.Error)
+	if !ok || resp != nil {
+		t.Fatalf("Expected *hubspot.Error, got %v, %v", resp, err)
+	}
+	if herr.StatusCode != http.StatusBadRequest || !reflect.DeepEqual(herr.Fields(), []string{"email"}) {
+		t.Fatalf("Unexpected error: %d %v", herr.StatusCode, herr.Fields())
+	}
+}
+
+func TestHubSpotContext(t *testing.T) {
+
+	r := httptest.NewRequest("GET", "http://internal:8080/signup?plan=pro", nil)
+	r.RemoteAddr = "10.0.0.5:51234"
+	r.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.9")
+	r.Header.Set("X-Forwarded-Proto", "https")
+	r.Header.Set("X-Forwarded-Host", "www.example.com")
+
+	// Untrusted proxy: headers ignored, no cookie
+	ctx := hubspot.NewContext(r, hubspot.ContextOptions{PageName: "Signup"})
+	expected := hubspot.Context{
+		IpAddress: "10.0.0.5",
+		PageUri:   "http://internal:8080/signup?plan=pro",
+		PageName:  "Signup",
+	}
+	if ctx != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, ctx)
+	}
+
+	// Trusted proxy
+	r.AddCookie(&http.Cookie{Name: "hubspotutk", Value: "abc"})
+	ctx = hubspot.NewContext(r, hubspot.ContextOptions{TrustedProxies: []string{"10.0.0.0/8"}})
+	expected = hubspot.Context{
+		Hutk:      "abc",
+		IpAddress: "203.0.113.7",
+		PageUri:   "https://www.example.com/signup?plan=pro",
+	}
+	if ctx != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, ctx)
+	}
+}
+
+func TestHubSpotEventBatcher(t *testing.T) {
+
+	rec := &hubspot.EventRecorder{}
+	batch := hubspot.NewEventBatcher(rec, 3)
+
+	batch.Add(hubspot.BehavioralEvent{EventName: "pe1_signup", Email: "jo@example.com"})
+	batch.AddTimeline(hubspot.TimelineEvent{EventTemplateId: "1001", Email: "jo@example.com"})
+	if rec.Batches != 0 || batch.Pending() != 2 {
+		t.Fatalf("Expected no flush before batch is full, got %d batches", rec.Batches)
+	}
+
+	// Third event fills the batch
+	batch.Add(hubspot.BehavioralEvent{EventName: "pe1_upgrade", ObjectId: "51"})
+	if len(rec.Events) != 2 || len(rec.Timeline) != 1 || batch.Pending() != 0 {
+		t.Fatalf("Expected full flush, got %d events, %d timeline", len(rec.Events), len(rec.Timeline))
+	}
+	if rec.Events[0].OccurredAt.IsZero() {
+		t.Fatal("Expected event time set when queued")
+	}
+
+	// Failed flush keeps events
+	rec.Err = errors.New("down")
+	batch.Add(hubspot.BehavioralEvent{EventName: "pe1_login", Email: "jo@example.com"})
+	if err := batch.Flush(); err == nil || batch.Pending() != 1 {
+		t.Fatalf("Expected events kept after failed flush, pending %d", batch.Pending())
+	}
+
+	rec.Err = nil
+	if err := batch.Flush(); err != nil || len(rec.Events) != 3 {
+		t.Fatalf("Expected retried event sent, got %d events, %v", len(rec.Events), err)
+	}
+
+	// Second API batch fails, only its events are retried
+	rec = &hubspot.EventRecorder{Err: errors.New("down"), ErrAfter: 1}
+	batch = hubspot.NewEventBatcher(rec, 0)
+	for i := 0; i < 700; i++ {
+		batch.Add(hubspot.BehavioralEvent{EventName: "pe1_view", ObjectId: strconv.Itoa(i)})
+	}
+	if err := batch.Flush(); err == nil || len(rec.Events) != 500 || batch.Pending() != 200 {
+		t.Fatalf("Expected first batch sent, got %d events, pending %d", len(rec.Events), batch.Pending())
+	}
+
+	rec.Err = nil
+	if err := batch.Flush(); err != nil || len(rec.Events) != 700 || rec.Events[500].ObjectId != "500" {
+		t.Fatalf("Expected no event resent, got %d events, %v", len(rec.Events), err)
+	}
+}
+
+func TestHubSpotWebhook(t *testing.T) {
+
+	var got []hubspot.WebhookEvent
+	wh := hubspot.NewWebhookHandler("secret")
+	wh.On("contact.propertyChange", func(ev hubspot.WebhookEvent) error {
+		got = append(got, ev)
+		return nil
+	})
+
+	body := `[{"eventId":1,"subscriptionType":"contact.propertyChange","objectId":51,"propertyName":"lifecyclestage","propertyValue":"customer"},` +
+		`{"eventId":2,"subscriptionType":"contact.creation","objectId":52}]`
+
+	send := func(ts time.Time, secret string) int {
+		stamp := strconv.FormatInt(ts.UnixNano()/int64(time.Millisecond), 10)
+		r := httptest.NewRequest("POST", "https://example.com/hubspot/webhook?app=1", strings.NewReader(body))
+		r.Header.Set("X-HubSpot-Request-Timestamp", stamp)
+		r.Header.Set("X-HubSpot-Signature-v3",
+			hubspot.SignatureV3(secret, "POST", "https://example.com/hubspot/webhook?app=1", []byte(body), stamp))
+		w := httptest.NewRecorder()
+		wh.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := send(time.Now(), "wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("Expected bad signature rejected, got %d", code)
+	}
+	if code := send(time.Now().Add(-10*time.Minute), "secret"); code != http.StatusUnauthorized {
+		t.Fatalf("Expected replay rejected, got %d", code)
+	}
+	if code := send(time.Now(), "secret"); code != http.StatusNoContent {
+		t.Fatalf("Expected batch accepted, got %d", code)
+	}
+
+	if len(got) != 1 || got[0].ObjectId != 51 || got[0].PropertyValue != "customer" {
+		t.Fatalf("Unexpected events routed: %+v", got)
+	}
+
+	// Without secret, even a matching empty-key signature is rejected
+	wh.ClientSecret = ""
+	if code := send(time.Now(), ""); code != http.StatusUnauthorized {
+		t.Fatalf("Expected request rejected without secret, got %d", code)
+	}
+	if len(got) != 1 {
+		t.Fatalf("Events routed without secret: %+v", got)
+	}
+}
+
+func TestHubSpotSubmitter(t *testing.T) {
+
+	fname := filepath.Join(t.TempDir(), "queue.log")
+	store, err := hubspot.NewFileQueueStore(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	sub := hubspot.NewSubmitter(store)
+	sub.MinBackoff = 0
+	sub.MaxAttempts = 2
+	sub.SubmitFunc = func(portalId, formId string, form map[string]string) error {
+		calls++
+		if form["email"] == "down@example.com" {
+			return errors.New("HubSpot down")
+		}
+		return nil
+	}
+
+	sub.Enqueue("signup-1", "123", "form", map[string]string{"email": "jo@example.com"})
+	sub.Enqueue("signup-1", "123", "form", map[string]string{"email": "jo@example.com"})
+	sub.Enqueue("signup-2", "123", "form", map[string]string{"email": "down@example.com"})
+
+	// Survives restart
+	if store, err = hubspot.NewFileQueueStore(fname); err != nil {
+		t.Fatal(err)
+	}
+	sub.Store = store
+	if stats, _ := sub.Stats(); stats.Pending != 2 {
+		t.Fatalf("Expected 2 pending after reopen, got %+v", stats)
+	}
+
+	sub.Process()
+	sub.Process()
+	stats, _ := sub.Stats()
+	if calls != 3 || stats.Succeeded != 1 || stats.Failed != 1 || stats.Pending != 0 || stats.Retries != 2 {
+		t.Fatalf("Unexpected stats after processing: %d calls, %+v", calls, stats)
+	}
+
+	// Sent key is not queued again
+	sub.Enqueue("signup-1", "123", "form", map[string]string{"email": "jo@example.com"})
+	if stats, _ := sub.Stats(); stats.Pending != 0 {
+		t.Fatalf("Expected duplicate ignored, got %+v", stats)
+	}
+
+	// Nor after restart and compaction
+	if err = store.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if store, _ = hubspot.NewFileQueueStore(fname); store == nil {
+		t.Fatal("Cannot reopen compacted store")
+	}
+	sub = hubspot.NewSubmitter(store)
+	sub.Enqueue("signup-1", "123", "form", map[string]string{"email": "jo@example.com"})
+	if stats, _ := sub.Stats(); stats.Pending != 0 || stats.Failed != 1 {
+		t.Fatalf("Expected duplicate ignored after reopen, got %+v", stats)
+	}
+
+	// Tombstones are dropped after DedupWindow
+	sub.DedupWindow = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	sub.Process()
+	if err = store.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if store, _ = hubspot.NewFileQueueStore(fname); store == nil {
+		t.Fatal("Cannot reopen compacted store")
+	}
+	if subs, _ := store.All(); len(subs) != 1 || !subs[0].Failed {
+		t.Fatalf("Expected single failed submission after compaction, got %+v", subs)
+	}
+}
+
+// Store failing to list submissions.
+type brokenQueueStore struct {
+	*hubspot.MemoryQueueStore
+}
+
+func (bs brokenQueueStore) All() ([]hubspot.QueuedSubmission, error) {
+	return nil, errors.New("store down")
+}
+
+func TestHubSpotSubmitterErrors(t *testing.T) {
+
+	// 4xx other than 429 is not retried
+	sub := hubspot.NewSubmitter(hubspot.NewMemoryQueueStore())
+	sub.MinBackoff = 0
+	calls := map[string]int{}
+	sub.SubmitFunc = func(portalId, formId string, form map[string]string) error {
+		calls[form["email"]]++
+		switch form["email"] {
+		case "bad":
+			return &hubspot.Error{StatusCode: http.StatusBadRequest, Message: "invalid email"}
+		case "busy@example.com":
+			return &hubspot.Error{StatusCode: http.StatusTooManyRequests, Message: "rate limited"}
+		}
+		return nil
+	}
+
+	sub.Enqueue("bad", "123", "form", map[string]string{"email": "bad"})
+	sub.Enqueue("busy", "123", "form", map[string]string{"email": "busy@example.com"})
+	sub.Process()
+	sub.Process()
+
+	stats, _ := sub.Stats()
+	if calls["bad"] != 1 || calls["busy@example.com"] != 2 || stats.Failed != 1 || stats.Pending != 1 {
+		t.Fatalf("Unexpected processing: %v calls, %+v", calls, stats)
+	}
+
+	// Background errors are reported
+	errs := make(chan error, 1)
+	sub = hubspot.NewSubmitter(brokenQueueStore{hubspot.NewMemoryQueueStore()})
+	sub.OnError = func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}
+	sub.Start()
+	defer sub.Stop()
+
+	select {
+	case err := <-errs:
+		if err.Error() != "store down" {
+			t.Fatalf("Unexpected error reported: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Processing error not reported")
+	}
+}
+
+func TestHubSpotValidate(t *testing.T) {
+
+	form := &hubspot.Form{
+		FieldGroups: []hubspot.FieldGroup{
+			{Fields: []hubspot.FormField{
+				{Name: "email", FieldType: "email", Required: true,
+					Validation: hubspot.FieldValidation{BlockedEmailDomains: []string{"gmail.com"}}},
+				{Name: "firstname", FieldType: "single_line_text", Required: true},
+				{Name: "phone", FieldType: "phone", Validation: hubspot.FieldValidation{MinAllowedDigits: 7}},
+			}},
+			{Fields: []hubspot.FormField{
+				{Name: "plan", FieldType: "dropdown", Options: []hubspot.FieldOption{{Value: "free"}, {Value: "pro"}}},
+				{Name: "utm", FieldType: "single_line_text", Required: true, Hidden: true},
+			}},
+		},
+	}
+
+	err := hubspot.Validate(form, map[string]string{
+		"email": "jo@gmail.com",
+		"phone": "123",
+		"plan":  "enterprise",
+	})
+	verr, ok := err.(*hubspot.ValidationError)
+	if !ok {
+		t.Fatalf("Expected ValidationError, got %v", err)
+	}
+	if fields := verr.Fields(); !reflect.DeepEqual(fields, []string{"email", "firstname", "phone", "plan"}) {
+		t.Fatalf("Unexpected failing fields: %v", fields)
+	}
+
+	err = hubspot.Validate(form, map[string]string{
+		"email":     "jo@example.com",
+		"firstname": "Jo",
+		"phone":     "+1 555 010 2030",
+		"plan":      "pro",
+	})
+	if err != nil {
+		t.Fatalf("Expected valid values, got %v", err)
+	}
+}
+
+func TestHubSpotCRM(t *testing.T) {
+
+	var bodies []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var body map[string]interface{}
+		if r.Body != nil {
+			json.NewDecoder(r.Body).Decode(&body)
+		}
+		bodies = append(bodies, body)
+
+		switch r.Method + " " + r.URL.EscapedPath() {
+		case "GET /crm/v3/objects/contacts/jo@example.com":
+			if q := r.URL.Query(); q.Get("idProperty") != "email" || q.Get("properties") != "plan,lifecyclestage" {
+				t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"id":"101","properties":{"email":"jo@example.com","plan":"pro"}}`))
+		case "POST /crm/v3/objects/contacts/batch/upsert":
+			w.Write([]byte(`{"results":[{"id":"101"},{"id":"102"}]}`))
+		case "POST /crm/v3/objects/contacts/search":
+			w.Write([]byte(`{"total":1,"results":[{"id":"101"}],"paging":{"next":{"after":"1"}}}`))
+		case "PATCH /crm/v3/objects/contacts/a%2Fb":
+			w.Write([]byte(`{"id":"a/b"}`))
+		case "DELETE /crm/v3/objects/contacts/a%2Fb":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	crm := &hubspot.CRM{Token: "token", BaseUrl: srv.URL, HTTPClient: srv.Client()}
+	contacts := crm.Contacts()
+
+	// GetByEmail
+	obj, err := contacts.GetByEmail("jo@example.com", "plan", "lifecyclestage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Id != "101" || obj.Properties["plan"] != "pro" {
+		t.Fatalf("Unexpected contact: %+v", obj)
+	}
+
+	// UpsertByEmail, sent in email order
+	objs, err := contacts.UpsertByEmail(map[string]hubspot.Properties{
+		"zed@example.com": {"plan": "free"},
+		"jo@example.com":  {"plan": "pro"},
+	})
+	if err != nil || len(objs) != 2 {
+		t.Fatalf("Unexpected upsert result: %v, %v", objs, err)
+	}
+	inputs := bodies[1]["inputs"].([]interface{})
+	first := inputs[0].(map[string]interface{})
+	if len(inputs) != 2 || first["id"] != "jo@example.com" || first["idProperty"] != "email" {
+		t.Fatalf("Unexpected upsert inputs: %v", inputs)
+	}
+
+	// BatchUpsert leaves caller's inputs untouched
+	ins := []hubspot.UpsertInput{{Id: "jo@example.com", Properties: hubspot.Properties{"plan": "pro"}}}
+	if _, err = contacts.BatchUpsert("email", ins); err != nil {
+		t.Fatal(err)
+	}
+	if ins[0].IdProperty != "" {
+		t.Fatalf("Caller's inputs modified: %+v", ins)
+	}
+	if bodies[2]["inputs"].([]interface{})[0].(map[string]interface{})["idProperty"] != "email" {
+		t.Fatalf("idProperty not sent: %v", bodies[2])
+	}
+
+	// Search
+	res, err := contacts.Search(hubspot.SearchRequest{
+		FilterGroups: []hubspot.FilterGroup{{Filters: []hubspot.Filter{{PropertyName: "plan", Operator: "EQ", Value: "pro"}}}},
+		Limit:        1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 || len(res.Results) != 1 || res.Paging.NextAfter() != "1" {
+		t.Fatalf("Unexpected search result: %+v", res)
+	}
+	if bodies[3]["limit"] != float64(1) || bodies[3]["filterGroups"] == nil {
+		t.Fatalf("Unexpected search request: %v", bodies[3])
+	}
+
+	// Ids are path escaped
+	if _, err = contacts.Update("a/b", hubspot.Properties{"plan": "pro"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = contacts.Archive("a/b"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Errors
+	if _, err = contacts.GetByEmail("nobody@example.com"); err == nil {
+		t.Fatal("Expected error for missing contact")
+	} else if herr, ok := err.(*hubspot.Error); !ok || herr.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected 404 *hubspot.Error, got %v", err)
+	}
+}
+
+func TestHubSpotGetForm(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" || r.URL.Path != "/marketing/v3/forms/F1" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"id":"F1","name":"Signup","formType":"hubspot","fieldGroups":[` +
+			`{"groupType":"default_group","fields":[{"name":"email","fieldType":"email","required":true,` +
+			`"validation":{"blockedEmailDomains":["gmail.com"]}}]},` +
+			`{"groupType":"default_group","fields":[{"name":"plan","fieldType":"dropdown","options":[{"value":"free"},{"value":"pro"}]}]}]}`))
+	}))
+	defer srv.Close()
+
+	crm := &hubspot.CRM{Token: "token", BaseUrl: srv.URL}
+	form, err := crm.GetForm("F1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := form.Fields()
+	if form.Name != "Signup" || len(fields) != 2 || !fields[0].Required || fields[1].Options[1].Value != "pro" {
+		t.Fatalf("Unexpected form: %+v", form)
+	}
+
+	err = hubspot.Validate(form, map[string]string{"email": "jo@gmail.com", "plan": "pro"})
+	if verr, ok := err.(*hubspot.ValidationError); !ok || !reflect.DeepEqual(verr.Fields(), []string{"email"}) {
+		t.Fatalf("Expected blocked domain rejected, got %v", err)
+	}
+
+	if form, err = crm.GetForm("missing"); form != nil || err == nil {
+		t.Fatalf("Expected error for missing form, got %+v, %v", form, err)
+	}
+
+	// Missing definition is an error, not a panic
+	if err = hubspot.Validate(nil, map[string]string{"email": "jo@example.com"}); err == nil {
+		t.Fatal("Expected error for nil form")
+	}
+}
+
+func TestHubSpotPaging(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		q := r.URL.Query()
+		switch {
+		case r.URL.Path == "/crm/v3/objects/deals" && q.Get("after") == "":
+			if q.Get("properties") != "dealname" {
+				t.Errorf("Properties not sent: %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"results":[{"id":"1"},{"id":"2"}],"paging":{"next":{"after":"2"}}}`))
+		case r.URL.Path == "/crm/v3/objects/deals" && q.Get("after") == "2":
+			w.Write([]byte(`{"results":[{"id":"3"}]}`))
+		case r.URL.Path == "/crm/v4/objects/deals/1/associations/companies":
+			w.Write([]byte(`{"results":[{"toObjectId":51}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	crm := hubspot.NewCRM("token")
+	crm.BaseUrl = srv.URL
+
+	var ids []string
+	it := crm.Deals().List("dealname")
+	for it.Next() {
+		ids = append(ids, it.Object().Id)
+	}
+	if it.Err() != nil || strings.Join(ids, ",") != "1,2,3" {
+		t.Fatalf("Unexpected deals: %v, %v", ids, it.Err())
+	}
+
+	ait := crm.Associations("deals", "1", "companies")
+	if !ait.Next() || ait.Association().ToObjectId != 51 || ait.Next() || ait.Err() != nil {
+		t.Fatalf("Unexpected associations: %+v, %v", ait.Association(), ait.Err())
+	}
+}
+
+func TestHubSpotOAuthRefresh(t *testing.T) {
+
+	const callers = 4
+
+	var mu sync.Mutex
+	refreshes := 0
+	var rejected sync.WaitGroup
+	rejected.Add(callers)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/oauth/v1/token":
+			r.ParseForm()
+			mu.Lock()
+			refreshes++
+			mu.Unlock()
+			if r.PostForm.Get("refresh_token") != "refresh" {
+				t.Errorf("Unexpected refresh token: %v", r.PostForm)
+			}
+			w.Write([]byte(`{"access_token":"fresh","expires_in":1800}`))
+		case r.Header.Get("Authorization") == "Bearer stale":
+			// all callers are rejected before any refreshes
+			rejected.Done()
+			rejected.Wait()
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"status":"error","category":"EXPIRED_AUTHENTICATION"}`))
+		default:
+			w.Write([]byte(`{"id":"1"}`))
+		}
+	}))
+	defer srv.Close()
+
+	oa := &hubspot.OAuth{ClientId: "id", ClientSecret: "secret", BaseUrl: srv.URL}
+	oa.SaveToken("portal", &hubspot.Token{AccessToken: "stale", RefreshToken: "refresh", ExpiresAt: time.Now().Add(time.Hour)})
+	crm := hubspot.NewCRMOAuth(oa, "portal")
+
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := crm.Deals().Get("1"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if refreshes != 1 {
+		t.Fatalf("Expected single refresh for concurrent 401s, got %d", refreshes)
+	}
+
+	// Token refreshed meanwhile is returned as is
+	if tok, err := crm.Auth.Refresh("stale"); err != nil || tok != "fresh" || refreshes != 1 {
+		t.Fatalf("Unexpected refresh: %q, %v, %d refreshes", tok, err, refreshes)
+	}
+	if tok, err := crm.Auth.Refresh("fresh"); err != nil || tok != "fresh" || refreshes != 2 {
+		t.Fatalf("Expected rejected token refreshed: %q, %v, %d refreshes", tok, err, refreshes)
+	}
+}
+
+func TestHubSpotOAuthPortals(t *testing.T) {
+
+	// Refresh of one portal does not wait for another
+	started := make(chan string, 2)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/oauth/v1/token":
+			r.ParseForm()
+			started <- r.PostForm.Get("refresh_token")
+			<-release
+			w.Write([]byte(`{"access_token":"fresh-` + r.PostForm.Get("refresh_token") + `","expires_in":1800}`))
+		case r.Method == "GET" && r.URL.Path == "/oauth/v1/access-tokens/fresh-a":
+			w.Write([]byte(`{"token":"fresh-a","hub_id":101,"scopes":["crm.objects.contacts.read"]}`))
+		case r.Method == "DELETE" && r.URL.Path == "/oauth/v1/refresh-tokens/a":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	oa := &hubspot.OAuth{ClientId: "id", ClientSecret: "secret", BaseUrl: srv.URL}
+	expired := time.Now().Add(-time.Hour)
+	oa.SaveToken("a", &hubspot.Token{AccessToken: "stale", RefreshToken: "a", ExpiresAt: expired})
+	oa.SaveToken("b", &hubspot.Token{AccessToken: "stale", RefreshToken: "b", ExpiresAt: expired})
+
+	toks := make(chan string, 2)
+	for _, key := range []string{"a", "b"} {
+		crm := hubspot.NewCRMOAuth(oa, key)
+		go func() {
+			tok, err := crm.Auth.Token()
+			if err != nil {
+				t.Error(err)
+			}
+			toks <- tok
+		}()
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-started:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Portal refreshes serialized")
+		}
+	}
+	close(release)
+	if got := map[string]bool{<-toks: true, <-toks: true}; !got["fresh-a"] || !got["fresh-b"] {
+		t.Fatalf("Unexpected tokens: %v", got)
+	}
+
+	// Introspect and Revoke use BaseUrl
+	info, err := oa.Introspect("fresh-a")
+	if err != nil || info.HubId != 101 {
+		t.Fatalf("Unexpected token info: %+v, %v", info, err)
+	}
+	if err = oa.Revoke("a"); err != nil {
+		t.Fatal(err)
+	}
+}
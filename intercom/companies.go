@@ -0,0 +1,133 @@
+package intercom
+
+/*
+
+example:
+
+company, err := ic.Companies().Upsert(intercom.Company{
+    CompanyID:    "acme",
+    Name:         "Acme",
+    MonthlySpend: 490,
+})
+...
+err = ic.Companies().AddUser("acme", intercom.User{UserID: "42"})
+
+*/
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+//------------------------------------------------------------
+// Companies
+//------------------------------------------------------------
+
+// Companies is companies client.
+type Companies struct {
+	ic *Intercom
+}
+
+func (ic *Intercom) Companies() *Companies {
+	return &Companies{ic: ic}
+}
+
+// Creates or updates company identified by CompanyID.
+func (cs *Companies) Upsert(company Company) (company1 Company, err error) {
+
+	if company.CompanyID == "" {
+		err = errors.New("Intercom company upsert: missing company_id")
+		return
+	}
+
+	return cs.send("POST", API_COMPANIES, nil, company.writable())
+}
+
+// Retrieves company by Intercom id.
+func (cs *Companies) Get(id string) (company Company, err error) {
+	return cs.send("GET", fmt.Sprintf("%s/%s", API_COMPANIES, id), nil, nil)
+}
+
+// Retrieves company by own company id.
+func (cs *Companies) FindByCompanyID(companyID string) (company Company, err error) {
+	return cs.send("GET", API_COMPANIES, companyParams{CompanyID: companyID}, nil)
+}
+
+// Attaches user to company, company is created if missing.
+func (cs *Companies) AddUser(companyID string, user User) error {
+	return cs.setUser(companyID, user, false)
+}
+
+// Detaches user from company, nothing is done if company is missing.
+func (cs *Companies) RemoveUser(companyID string, user User) error {
+	return cs.setUser(companyID, user, true)
+}
+
+//------------------------------------------------------------
+// Private methods
+//------------------------------------------------------------
+
+// Sends request returning single company.
+func (cs *Companies) send(method, url string, queryParams interface{}, payload map[string]interface{}) (company Company, err error) {
+
+	var data []byte
+	data, err = cs.ic.sendRequest(method, url, queryParams, payload)
+	if err != nil {
+		return
+	}
+
+	err = decode(data, &company)
+
+	return
+}
+
+// Company by own company id, ok is false when there is none.
+func (cs *Companies) find(companyID string) (company Company, ok bool, err error) {
+
+	if company, err = cs.FindByCompanyID(companyID); err == nil {
+		return company, true, nil
+	}
+
+	var ierr *ErrorList
+	if errors.As(err, &ierr) && ierr.StatusCode == http.StatusNotFound {
+		return Company{}, false, nil
+	}
+
+	return
+}
+
+func (cs *Companies) setUser(companyID string, user User, remove bool) (err error) {
+
+	companies := []Company{{CompanyID: companyID, Remove: remove}}
+
+	if cs.ic.legacy() {
+		if remove {
+			// detaching never creates missing user or company
+			var ok bool
+			if user, ok, err = cs.ic.Users().findLegacy(user); err != nil || !ok {
+				return
+			}
+			if _, ok, err = cs.find(companyID); err != nil || !ok {
+				return
+			}
+		}
+
+		// API 1.x updates user companies, other fields untouched
+		_, err = cs.ic.Users().Upsert(User{
+			ID:        user.ID,
+			UserID:    user.UserID,
+			Email:     user.Email,
+			Companies: &CompanyList{Companies: companies},
+		})
+		return
+	}
+
+	var id string
+	if id, err = cs.ic.Users().contactID(user); err != nil {
+		return
+	}
+
+	_, err = cs.ic.syncCompaniesV2(id, companies)
+	return
+}
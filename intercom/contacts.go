@@ -0,0 +1,296 @@
+package intercom
+
+import (
+	"errors"
+	"fmt"
+)
+
+//------------------------------------------------------------
+// Contacts: API 2.x unified users and leads
+//------------------------------------------------------------
+
+// Contact roles.
+const (
+	ROLE_USER = "user"
+	ROLE_LEAD = "lead"
+)
+
+// Contacts is API 2.x contacts client.
+type Contacts struct {
+	ic *Intercom
+}
+
+func (ic *Intercom) Contacts() *Contacts {
+	return &Contacts{ic: ic}
+}
+
+// Creates contact, Role defaults to user.
+func (cs *Contacts) Create(contact Contact) (contact1 Contact, err error) {
+
+	if contact.Role == "" {
+		contact.Role = ROLE_USER
+	}
+
+	return cs.send("POST", API_CONTACTS, contact.writable())
+}
+
+// Updates contact identified by ID.
+func (cs *Contacts) Update(contact Contact) (contact1 Contact, err error) {
+
+	if contact.ID == "" {
+		err = errors.New("Intercom contact update: missing id")
+		return
+	}
+
+	return cs.send("PUT", fmt.Sprintf("%s/%s", API_CONTACTS, contact.ID), contact.writable())
+}
+
+// Retrieves contact by id.
+func (cs *Contacts) Get(id string) (contact Contact, err error) {
+	return cs.send("GET", fmt.Sprintf("%s/%s", API_CONTACTS, id), nil)
+}
+
+// Finds contact by email and role, ok is false when there is none.
+func (cs *Contacts) FindByEmail(email, role string) (contact Contact, ok bool, err error) {
+
+	query := And(Eq("email", email))
+	if role != "" {
+		query.Queries = append(query.Queries, Eq("role", role))
+	}
+
+	return cs.findOne(query)
+}
+
+// Finds user by external id, ok is false when there is none.
+func (cs *Contacts) FindByExternalID(externalID string) (contact Contact, ok bool, err error) {
+	return cs.findOne(Eq("external_id", externalID))
+}
+
+// Updates contact with the same email and role, creates it otherwise.
+func (cs *Contacts) UpsertByEmail(contact Contact) (contact1 Contact, err error) {
+
+	if contact.Role == "" {
+		contact.Role = ROLE_USER
+	}
+
+	var existing Contact
+	var ok bool
+	if existing, ok, err = cs.FindByEmail(contact.Email, contact.Role); err != nil {
+		return
+	}
+
+	if !ok {
+		return cs.Create(contact)
+	}
+
+	contact.ID = existing.ID
+	return cs.Update(contact)
+}
+
+// Merges lead into user, lead is deleted.
+func (cs *Contacts) Merge(leadID, userID string) (contact Contact, err error) {
+
+	req := map[string]interface{}{
+		"from": leadID,
+		"into": userID,
+	}
+
+	return cs.send("POST", API_CONTACTS_MERGE, req)
+}
+
+// Archives contact.
+func (cs *Contacts) Archive(id string) (contact Contact, err error) {
+	return cs.send("POST", fmt.Sprintf("%s/%s/archive", API_CONTACTS, id), nil)
+}
+
+// Unarchives contact.
+func (cs *Contacts) Unarchive(id string) (contact Contact, err error) {
+	return cs.send("POST", fmt.Sprintf("%s/%s/unarchive", API_CONTACTS, id), nil)
+}
+
+// Deletes contact permanently.
+func (cs *Contacts) Delete(id string) (contact Contact, err error) {
+	return cs.send("DELETE", fmt.Sprintf("%s/%s", API_CONTACTS, id), nil)
+}
+
+//------------------------------------------------------------
+// Private methods
+//------------------------------------------------------------
+
+// Sends request returning single contact.
+func (cs *Contacts) send(method, url string, payload map[string]interface{}) (contact Contact, err error) {
+
+	var data []byte
+	data, err = cs.ic.sendRequest(method, url, nil, payload)
+	if err != nil {
+		return
+	}
+
+	err = decode(data, &contact)
+
+	return
+}
+
+// First contact matching query.
+func (cs *Contacts) findOne(query Query) (contact Contact, ok bool, err error) {
+
+	var page ContactPage
+	page, err = cs.Search(SearchRequest{Query: query})
+	if err != nil || len(page.Data) == 0 {
+		return
+	}
+
+	return page.Data[0], true, nil
+}
+
+// Sends search request returning one page of contacts.
+func (cs *Contacts) search(payload map[string]interface{}) (page ContactPage, err error) {
+
+	var data []byte
+	data, err = cs.ic.sendRequest("POST", API_CONTACTS_SEARCH, nil, payload)
+	if err != nil {
+		return
+	}
+
+	err = decode(data, &page)
+
+	return
+}
+
+// Lists users page by page via API 2.x contacts search.
+func (ic *Intercom) listUsersV2(page int64, order, sort string) (userList UserList, err error) {
+
+	var cp ContactPage
+	if cp, err = ic.listRoleV2(ROLE_USER, page, order, sort); err != nil {
+		return
+	}
+
+	userList.Pages = PageParams{Page: cp.Pages.Page, PerPage: cp.Pages.PerPage, TotalPages: cp.Pages.TotalPages}
+	for _, c := range cp.Data {
+		userList.Users = append(userList.Users, c.toUser())
+	}
+
+	return
+}
+
+// Lists leads page by page via API 2.x contacts search.
+func (ic *Intercom) listLeadsV2(page int64, order, sort string) (contactList ContactList, err error) {
+
+	var cp ContactPage
+	if cp, err = ic.listRoleV2(ROLE_LEAD, page, order, sort); err != nil {
+		return
+	}
+
+	contactList.Pages = PageParams{Page: cp.Pages.Page, PerPage: cp.Pages.PerPage, TotalPages: cp.Pages.TotalPages}
+	contactList.Contacts = cp.Data
+
+	return
+}
+
+// Gets given page of contacts with role, following cursor
+// pagination up to the page. Takes API 1.x order and sort.
+// Cursors are cached, so walking pages 1..N in order costs one
+// request per page.
+func (ic *Intercom) listRoleV2(role string, page int64, order, sort string) (cp ContactPage, err error) {
+
+	if page < 1 {
+		page = 1
+	}
+
+	// API 1.x sort fields
+	if sort == "last_request_at" {
+		sort = "last_seen_at"
+	}
+	if sort == "" {
+		sort = "created_at"
+	}
+	if order == "asc" {
+		order = "ascending"
+	} else {
+		order = "descending"
+	}
+
+	req := map[string]interface{}{
+		"query": map[string]interface{}{
+			"field":    "role",
+			"operator": "=",
+			"value":    role,
+		},
+		"sort": map[string]interface{}{
+			"field": sort,
+			"order": order,
+		},
+	}
+
+	// resume from closest cached page
+	key := role + "|" + sort + "|" + order
+	p, cursor := ic.pageCursor(key, page)
+
+	for ; p <= page; p++ {
+		pagination := map[string]interface{}{"per_page": 50}
+		if cursor != "" {
+			pagination["starting_after"] = cursor
+		}
+		req["pagination"] = pagination
+
+		if cp, err = ic.Contacts().search(req); err != nil {
+			return
+		}
+
+		if cursor = cp.StartingAfter(); cursor == "" && p < page {
+			// past last page
+			cp.Data = nil
+			break
+		}
+		if cursor != "" {
+			ic.savePageCursor(key, p+1, cursor)
+		}
+	}
+	cp.Pages.Page = page
+
+	return
+}
+
+// Writable fields of contact as request payload.
+func (c Contact) writable() map[string]interface{} {
+
+	m := map[string]interface{}{}
+	set := func(k string, v interface{}, ok bool) {
+		if ok {
+			m[k] = v
+		}
+	}
+
+	set("role", c.Role, c.Role != "")
+	set("external_id", c.ExternalID, c.ExternalID != "")
+	set("email", c.Email, c.Email != "")
+	set("phone", c.Phone, c.Phone != "")
+	set("name", c.Name, c.Name != "")
+	set("owner_id", c.OwnerID, c.OwnerID != 0)
+	set("signed_up_at", c.SignedUpAt, c.SignedUpAt != 0)
+	set("last_seen_at", c.LastSeenAt, c.LastSeenAt != 0)
+	set("unsubscribed_from_emails", c.UnsubscribedFromEmails, c.UnsubscribedFromEmails != nil)
+	set("custom_attributes", c.CustomAttributes, len(c.CustomAttributes) > 0)
+
+	return m
+}
+
+// Converts API 2.x contact to API 1.x user.
+func (c Contact) toUser() User {
+	return User{
+		ID:                     c.ID,
+		Email:                  c.Email,
+		Phone:                  c.Phone,
+		UserID:                 c.ExternalID,
+		Name:                   c.Name,
+		SignedUpAt:             c.SignedUpAt,
+		LastRequestAt:          c.LastSeenAt,
+		CreatedAt:              c.CreatedAt,
+		UpdatedAt:              c.UpdatedAt,
+		UnsubscribedFromEmails: c.UnsubscribedFromEmails,
+		CustomAttributes:       c.CustomAttributes,
+		Tags:                   c.Tags,
+		Segments:               c.Segments,
+		Companies:              c.Companies,
+	}
+}
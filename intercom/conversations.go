@@ -0,0 +1,489 @@
+package intercom
+
+/*
+
+example: open conversation when payment fails
+
+msg, err := ic.Conversations().Message(intercom.AdminMessage{
+    MessageType: intercom.MESSAGE_EMAIL,
+    Subject:     "Your payment failed",
+    Body:        "Hi, we could not charge your card...",
+    AdminID:     "123",
+    To:          intercom.Party{Type: "user", UserID: "42"},
+})
+...
+conv, err := ic.Conversations().Get(msg.ConversationID)
+conv, err = ic.Conversations().Assign(conv.ID, "123", "456", "")
+
+*/
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+//------------------------------------------------------------
+// Conversations model
+//------------------------------------------------------------
+
+// Message types.
+const (
+	MESSAGE_INAPP = "inapp"
+	MESSAGE_EMAIL = "email"
+)
+
+// Reply types.
+const (
+	REPLY_COMMENT = "comment"
+	REPLY_NOTE    = "note"
+)
+
+// Party is author or recipient: admin, user, lead or contact.
+// Written identified by ID, UserID (or ExternalID) or Email,
+// on API 2.x resolved to contact ID.
+type Party struct {
+	Type       string `json:"type,omitempty"`
+	ID         string `json:"id,omitempty"`
+	UserID     string `json:"user_id,omitempty"`
+	ExternalID string `json:"external_id,omitempty"`
+	Email      string `json:"email,omitempty"`
+	Name       string `json:"name,omitempty"`
+}
+
+// AdminMessage is message initiated by admin.
+// Subject and Template apply to email messages only,
+// Template is plain or personal.
+type AdminMessage struct {
+	MessageType string
+	Subject     string
+	Body        string
+	Template    string
+	AdminID     string
+	To          Party
+}
+
+// Message is created message.
+type Message struct {
+	Type           string `json:"type,omitempty"`
+	ID             string `json:"id,omitempty"`
+	CreatedAt      int64  `json:"created_at,omitempty"`
+	Subject        string `json:"subject,omitempty"`
+	Body           string `json:"body,omitempty"`
+	MessageType    string `json:"message_type,omitempty"`
+	ConversationID string `json:"conversation_id,omitempty"`
+	Owner          *Party `json:"owner,omitempty"`
+}
+
+// Reply to conversation by admin or user.
+// Admin replies set AdminID, user replies set User.
+type Reply struct {
+	MessageType    string // comment or note, note is admin only
+	AdminID        string
+	User           Party
+	Body           string
+	AttachmentURLs []string
+}
+
+// Conversation represents a Conversation within Intercom.
+// API 1.x sets User and Assignee, API 2.x Contacts and AdminAssigneeID.
+type Conversation struct {
+	Type              string                `json:"type,omitempty"`
+	ID                string                `json:"id,omitempty"`
+	Title             string                `json:"title,omitempty"`
+	CreatedAt         int64                 `json:"created_at,omitempty"`
+	UpdatedAt         int64                 `json:"updated_at,omitempty"`
+	WaitingSince      int64                 `json:"waiting_since,omitempty"`
+	SnoozedUntil      int64                 `json:"snoozed_until,omitempty"`
+	Open              bool                  `json:"open"`
+	State             string                `json:"state,omitempty"` // open, closed, snoozed
+	Read              bool                  `json:"read"`
+	Priority          string                `json:"priority,omitempty"`
+	User              *Party                `json:"user,omitempty"`
+	Assignee          *Party                `json:"assignee,omitempty"`
+	Contacts          *PartyList            `json:"contacts,omitempty"`
+	AdminAssigneeID   int64                 `json:"admin_assignee_id,omitempty"`
+	TeamAssigneeID    string                `json:"team_assignee_id,omitempty"`
+	Source            *ConversationSource   `json:"source,omitempty"`
+	ConversationParts *ConversationPartList `json:"conversation_parts,omitempty"`
+	Tags              *TagList              `json:"tags,omitempty"`
+}
+
+// PartyList holds API 2.x conversation contacts.
+type PartyList struct {
+	Type     string  `json:"type,omitempty"`
+	Contacts []Party `json:"contacts"`
+}
+
+// ConversationSource is the message conversation started with.
+type ConversationSource struct {
+	Type        string       `json:"type,omitempty"`
+	ID          string       `json:"id,omitempty"`
+	DeliveredAs string       `json:"delivered_as,omitempty"`
+	Subject     string       `json:"subject,omitempty"`
+	Body        string       `json:"body,omitempty"`
+	URL         string       `json:"url,omitempty"`
+	Author      *Party       `json:"author,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// ConversationPartList holds conversation parts, oldest first.
+type ConversationPartList struct {
+	Type              string             `json:"type,omitempty"`
+	ConversationParts []ConversationPart `json:"conversation_parts"`
+	TotalCount        int64              `json:"total_count,omitempty"`
+}
+
+// ConversationPart is single reply, note or action.
+// PartType: comment, note, assignment, close, open, ...
+type ConversationPart struct {
+	Type        string       `json:"type,omitempty"`
+	ID          string       `json:"id,omitempty"`
+	PartType    string       `json:"part_type,omitempty"`
+	Body        string       `json:"body,omitempty"`
+	CreatedAt   int64        `json:"created_at,omitempty"`
+	UpdatedAt   int64        `json:"updated_at,omitempty"`
+	NotifiedAt  int64        `json:"notified_at,omitempty"`
+	AssignedTo  *Party       `json:"assigned_to,omitempty"`
+	Author      *Party       `json:"author,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+type Attachment struct {
+	Type        string `json:"type,omitempty"`
+	Name        string `json:"name,omitempty"`
+	URL         string `json:"url,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Filesize    int64  `json:"filesize,omitempty"`
+}
+
+//------------------------------------------------------------
+// Conversations
+//------------------------------------------------------------
+
+// Conversations is conversations and messages client.
+type Conversations struct {
+	ic *Intercom
+}
+
+func (ic *Intercom) Conversations() *Conversations {
+	return &Conversations{ic: ic}
+}
+
+// Sends admin initiated in-app or email message.
+func (cs *Conversations) Message(msg AdminMessage) (msg1 Message, err error) {
+
+	if msg.MessageType == "" {
+		msg.MessageType = MESSAGE_INAPP
+	}
+
+	var to map[string]interface{}
+	if to, err = cs.party(msg.To); err != nil {
+		return
+	}
+
+	req := map[string]interface{}{
+		"message_type": msg.MessageType,
+		"body":         msg.Body,
+		"from":         map[string]interface{}{"type": "admin", "id": msg.AdminID},
+		"to":           to,
+	}
+	if msg.MessageType == MESSAGE_EMAIL {
+		req["subject"] = msg.Subject
+		if msg.Template == "" {
+			msg.Template = "plain"
+		}
+		req["template"] = msg.Template
+	}
+
+	return cs.sendMessage(API_MESSAGES, req)
+}
+
+// Creates conversation initiated by user or lead.
+func (cs *Conversations) Create(from Party, body string) (msg Message, err error) {
+
+	var party map[string]interface{}
+	if party, err = cs.party(from); err != nil {
+		return
+	}
+
+	req := map[string]interface{}{
+		"from": party,
+		"body": body,
+	}
+
+	return cs.sendMessage(API_CONVERSATIONS, req)
+}
+
+// Retrieves conversation with its parts.
+func (cs *Conversations) Get(id string) (conv Conversation, err error) {
+	return cs.send("GET", fmt.Sprintf("%s/%s", API_CONVERSATIONS, id), nil)
+}
+
+// Replies to conversation as admin or user.
+func (cs *Conversations) Reply(id string, reply Reply) (conv Conversation, err error) {
+
+	if reply.MessageType == "" {
+		reply.MessageType = REPLY_COMMENT
+	}
+
+	req := map[string]interface{}{
+		"message_type": reply.MessageType,
+		"body":         reply.Body,
+	}
+
+	if reply.AdminID != "" {
+		req["type"] = "admin"
+		req["admin_id"] = reply.AdminID
+	} else {
+		req["type"] = "user"
+		switch {
+		case reply.User.ID != "":
+			req["intercom_user_id"] = reply.User.ID
+		case reply.User.UserID != "":
+			req["user_id"] = reply.User.UserID
+		default:
+			req["email"] = reply.User.Email
+		}
+	}
+
+	if len(reply.AttachmentURLs) > 0 {
+		req["attachment_urls"] = reply.AttachmentURLs
+	}
+
+	return cs.send("POST", fmt.Sprintf("%s/%s/reply", API_CONVERSATIONS, id), req)
+}
+
+// Closes conversation, body is optional closing message.
+func (cs *Conversations) Close(id, adminID, body string) (conv Conversation, err error) {
+	return cs.part(id, "close", adminID, body, nil)
+}
+
+// Reopens conversation.
+func (cs *Conversations) Open(id, adminID string) (conv Conversation, err error) {
+	return cs.part(id, "open", adminID, "", nil)
+}
+
+// Assigns conversation to admin or team, body is optional note.
+func (cs *Conversations) Assign(id, adminID, assigneeID, body string) (conv Conversation, err error) {
+	return cs.part(id, "assignment", adminID, body, map[string]interface{}{
+		"assignee_id": assigneeID,
+	})
+}
+
+// Iterates over all conversations, newest first, without parts.
+// Waits out rate limits, stops when ctx is done.
+func (cs *Conversations) All(ctx context.Context) *ConversationIter {
+
+	ic := cs.ic
+
+	return newConversationIter(ctx, func(ctx context.Context, cursor string) (convs []Conversation, next string, err error) {
+
+		// API 1.x pages by number, cursor holds next page
+		var params interface{} = cursorParams{PerPage: iterPerPage, StartingAfter: cursor}
+		if ic.legacy() {
+			page, _ := strconv.ParseInt(cursor, 10, 64)
+			params = PageParams{Page: page, PerPage: 60}
+		}
+
+		var data []byte
+		data, err = ic.requestPatient(ctx, "GET", API_CONVERSATIONS, params, nil)
+		if err != nil {
+			return
+		}
+
+		var list struct {
+			Conversations []Conversation  `json:"conversations"`
+			Pages         json.RawMessage `json:"pages"`
+		}
+		if err = decode(data, &list); err != nil {
+			return
+		}
+
+		switch {
+		case len(list.Pages) == 0:
+			// single page, no paging info
+		case ic.legacy():
+			var pages PageParams
+			if err = json.Unmarshal(list.Pages, &pages); err != nil {
+				return
+			}
+			if pages.Page < pages.TotalPages {
+				next = strconv.FormatInt(pages.Page+1, 10)
+			}
+		default:
+			var cp ContactPage
+			if err = json.Unmarshal(list.Pages, &cp.Pages); err != nil {
+				return
+			}
+			next = cp.StartingAfter()
+		}
+
+		return list.Conversations, next, nil
+	})
+}
+
+// ConversationIter iterates over conversations across all pages.
+type ConversationIter struct {
+	pages pages
+	items []Conversation
+	cur   Conversation
+}
+
+func newConversationIter(ctx context.Context, fetch func(ctx context.Context, cursor string) ([]Conversation, string, error)) *ConversationIter {
+
+	it := &ConversationIter{}
+	it.pages = pages{ctx: ctx, fetch: func(ctx context.Context, cursor string) (n int, next string, err error) {
+		it.items, next, err = fetch(ctx, cursor)
+		return len(it.items), next, err
+	}}
+
+	return it
+}
+
+// Advances to the next conversation, fetching next page when needed.
+// Returns false when done or on error, see Err.
+func (it *ConversationIter) Next() bool {
+
+	if !it.pages.next() {
+		return false
+	}
+
+	it.cur, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// Current conversation.
+func (it *ConversationIter) Conversation() Conversation {
+	return it.cur
+}
+
+// First error encountered while fetching pages, if any.
+func (it *ConversationIter) Err() error {
+	return it.pages.err
+}
+
+//------------------------------------------------------------
+// Private methods
+//------------------------------------------------------------
+
+// Adds admin action part to conversation.
+// API 1.x takes actions as replies, API 2.x as parts.
+func (cs *Conversations) part(id, messageType, adminID, body string, extra map[string]interface{}) (conv Conversation, err error) {
+
+	req := map[string]interface{}{
+		"message_type": messageType,
+		"type":         "admin",
+		"admin_id":     adminID,
+	}
+	if body != "" {
+		req["body"] = body
+	}
+	for k, v := range extra {
+		req[k] = v
+	}
+
+	url := fmt.Sprintf("%s/%s/parts", API_CONVERSATIONS, id)
+	if cs.ic.legacy() {
+		url = fmt.Sprintf("%s/%s/reply", API_CONVERSATIONS, id)
+	}
+
+	return cs.send("POST", url, req)
+}
+
+// Sends request returning single conversation.
+func (cs *Conversations) send(method, url string, payload map[string]interface{}) (conv Conversation, err error) {
+
+	var data []byte
+	data, err = cs.ic.sendRequest(method, url, nil, payload)
+	if err != nil {
+		return
+	}
+
+	err = decode(data, &conv)
+
+	return
+}
+
+// Sends request returning created message.
+func (cs *Conversations) sendMessage(url string, payload map[string]interface{}) (msg Message, err error) {
+
+	var data []byte
+	data, err = cs.ic.sendRequest("POST", url, nil, payload)
+	if err != nil {
+		return
+	}
+
+	err = decode(data, &msg)
+
+	return
+}
+
+// Party identification as request payload. API 2.x only takes
+// contact id, UserID and Email are resolved to it.
+func (cs *Conversations) party(p Party) (m map[string]interface{}, err error) {
+
+	if cs.ic.legacy() {
+		return p.writable(), nil
+	}
+
+	typ := p.Type
+	if typ == "" {
+		typ = ROLE_USER
+	}
+
+	id := p.ID
+	if id == "" {
+		if typ == ROLE_LEAD {
+			if p.Email == "" {
+				return nil, errors.New("Intercom lead: missing id or email")
+			}
+
+			var contact Contact
+			var ok bool
+			if contact, ok, err = cs.ic.Contacts().FindByEmail(p.Email, ROLE_LEAD); err == nil && !ok {
+				err = fmt.Errorf("Intercom lead not found: %s", p.Email)
+			}
+			id = contact.ID
+		} else {
+			id, err = cs.ic.Users().contactID(User{UserID: p.userID(), Email: p.Email})
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	return map[string]interface{}{"type": typ, "id": id}, nil
+}
+
+// API 1.x party identification as request payload.
+func (p Party) writable() map[string]interface{} {
+
+	m := map[string]interface{}{}
+	if p.Type != "" {
+		m["type"] = p.Type
+	}
+
+	switch {
+	case p.ID != "":
+		m["id"] = p.ID
+	case p.userID() != "":
+		m["user_id"] = p.userID()
+	default:
+		m["email"] = p.Email
+	}
+
+	return m
+}
+
+// Own user id, set as UserID or API 2.x ExternalID.
+func (p Party) userID() string {
+
+	if p.UserID != "" {
+		return p.UserID
+	}
+
+	return p.ExternalID
+}
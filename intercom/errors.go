@@ -0,0 +1,258 @@
+package intercom
+
+/*
+
+example:
+
+_, err := ic.Contacts().Get(id)
+
+var ierr *intercom.ErrorList
+if errors.As(err, &ierr) && ierr.Has("not_found") {
+    ...
+}
+
+// wait for rate limit reset instead of failing with 429
+ic.WaitOnRateLimit = true
+fmt.Print(ic.RateLimit().Remaining)
+
+// rate limit seen by single call
+var rl intercom.RateLimit
+_, err = ic.WithRateLimit(&rl).Contacts().Get(id)
+fmt.Print(rl.Remaining)
+
+*/
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//------------------------------------------------------------
+// Constants
+//------------------------------------------------------------
+
+const (
+	// how many times to wait out rate limit for single request
+	maxRateLimitWaits = 5
+
+	// wait when X-RateLimit-Reset is missing
+	defaultRateLimitWait = 10 * time.Second
+)
+
+//------------------------------------------------------------
+// Errors
+//------------------------------------------------------------
+
+// ErrorList is error response of Intercom API, returned by all
+// calls on status >= 400. Use errors.As to get it.
+type ErrorList struct {
+	StatusCode int           `json:"-"`
+	Type       string        `json:"type"` // error.list
+	RequestID  string        `json:"request_id"`
+	Errors     []ErrorDetail `json:"errors"`
+	RateLimit  RateLimit     `json:"-"`
+}
+
+// ErrorDetail is single error, ie code "not_found".
+type ErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+}
+
+func (e *ErrorList) Error() string {
+
+	ss := []string{}
+	for _, d := range e.Errors {
+		s := d.Message
+		if d.Code != "" {
+			s = d.Code + ": " + s
+		}
+		if d.Field != "" {
+			s += " (" + d.Field + ")"
+		}
+		ss = append(ss, s)
+	}
+
+	if len(ss) == 0 {
+		return fmt.Sprintf("Intercom error %d", e.StatusCode)
+	}
+
+	return fmt.Sprintf("Intercom error %d: %s", e.StatusCode, strings.Join(ss, "; "))
+}
+
+// Tells if error list contains code.
+func (e *ErrorList) Has(code string) bool {
+
+	for _, d := range e.Errors {
+		if d.Code == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+//------------------------------------------------------------
+// Rate limits
+//------------------------------------------------------------
+
+// RateLimit is rate limit state reported with every response.
+type RateLimit struct {
+	Limit     int       // requests per window
+	Remaining int       // requests left in window
+	Reset     time.Time // when window resets
+}
+
+// Rate limit reported with the last response of any call made by
+// client or its copies, zero before first call. See WithRateLimit
+// for rate limit of a given call.
+func (ic *Intercom) RateLimit() RateLimit {
+
+	if ic.state == nil {
+		return RateLimit{}
+	}
+
+	ic.state.mu.Lock()
+	defer ic.state.mu.Unlock()
+
+	return ic.state.rateLimit
+}
+
+// Returns copy of client storing rate limit of each response it
+// gets into rl. Copy is meant for a single call or goroutine.
+func (ic *Intercom) WithRateLimit(rl *RateLimit) *Intercom {
+
+	ic1 := *ic
+	ic1.callRL = rl
+
+	return &ic1
+}
+
+//------------------------------------------------------------
+// Private methods
+//------------------------------------------------------------
+
+// Records rate limit of response.
+func (ic *Intercom) setRateLimit(rl RateLimit) {
+
+	if ic.callRL != nil {
+		*ic.callRL = rl
+	}
+
+	if ic.state == nil {
+		return
+	}
+
+	ic.state.mu.Lock()
+	ic.state.rateLimit = rl
+	ic.state.mu.Unlock()
+}
+
+// Parses error response, keeps non-JSON body as message.
+func parseError(statusCode int, data []byte, rl RateLimit) *ErrorList {
+
+	e := &ErrorList{}
+	if json.Unmarshal(data, e) != nil || len(e.Errors) == 0 {
+		e = &ErrorList{}
+		if body := strings.TrimSpace(string(data)); body != "" {
+			e.Errors = []ErrorDetail{{Message: body}}
+		}
+	}
+	e.StatusCode, e.RateLimit = statusCode, rl
+
+	return e
+}
+
+// Decodes response, failures keep start of body for context.
+func decode(data []byte, v interface{}) error {
+
+	if err := json.Unmarshal(data, v); err != nil {
+		body := string(data)
+		if len(body) > 200 {
+			body = body[:200] + "..."
+		}
+		return fmt.Errorf("Intercom: cannot decode response %q: %w", body, err)
+	}
+
+	return nil
+}
+
+// Parses X-RateLimit-* headers, ok is false when missing.
+func parseRateLimit(h http.Header) (rl RateLimit, ok bool) {
+
+	var err error
+	if rl.Remaining, err = strconv.Atoi(h.Get("X-RateLimit-Remaining")); err != nil {
+		return rl, false
+	}
+
+	rl.Limit, _ = strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rl.Reset = time.Unix(reset, 0)
+	}
+
+	return rl, true
+}
+
+// Sends request, when wait is set waiting until rate limit resets
+// if it is exhausted and retrying when Intercom responds 429.
+func (ic *Intercom) requestWait(ctx context.Context, method, url string, queryParams interface{}, payload map[string]interface{}, wait bool) (data []byte, err error) {
+
+	for i := 0; ; i++ {
+		if wait {
+			if rl := ic.RateLimit(); rl.Remaining == 0 && time.Now().Before(rl.Reset) {
+				if err = sleep(ctx, rateLimitWait(rl, time.Now())); err != nil {
+					return
+				}
+			}
+		}
+
+		var resp *http.Response
+		data, resp, err = ic.request(ctx, method, url, queryParams, payload)
+		if !wait || err == nil || resp == nil || resp.StatusCode != http.StatusTooManyRequests || i >= maxRateLimitWaits {
+			return
+		}
+
+		rl, _ := parseRateLimit(resp.Header)
+		if err = sleep(ctx, rateLimitWait(rl, time.Now())); err != nil {
+			return
+		}
+	}
+}
+
+// How long to wait until rate limit resets.
+func rateLimitWait(rl RateLimit, now time.Time) time.Duration {
+
+	if rl.Reset.IsZero() {
+		return defaultRateLimitWait
+	}
+
+	d := rl.Reset.Sub(now)
+	if d < time.Second {
+		// clock skew
+		d = time.Second
+	}
+	if d > time.Minute {
+		d = time.Minute
+	}
+
+	return d
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,420 @@
+package intercom
+
+/*
+
+example:
+
+tracker := intercom.NewTracker(&ic, 20, 5*time.Second)
+tracker.Start()
+defer tracker.Stop(context.Background())
+
+tracker.Track(intercom.Event{
+    EventName: "invoice-paid",
+    UserID:    "42",
+    Metadata: map[string]interface{}{
+        "price":   intercom.MonetaryAmount{Amount: 4900, Currency: "usd"},
+        "invoice": intercom.RichLink{URL: invoiceUrl, Value: "INV-0001"},
+        "seats":   5,
+    },
+})
+
+// in tests
+rec := &intercom.EventRecorder{}
+tracker = intercom.NewTracker(rec, 20, time.Second)
+
+*/
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------
+// Constants
+//------------------------------------------------------------
+
+const (
+	// Intercom limit of metadata keys per event
+	maxEventMetadata = 10
+
+	// Tracker flush interval when not set
+	defaultTrackerInterval = 10 * time.Second
+)
+
+var (
+	ErrTrackerFull    = errors.New("Intercom tracker: too many pending events")
+	ErrTrackerStopped = errors.New("Intercom tracker: stopped")
+)
+
+//------------------------------------------------------------
+// Events model
+//------------------------------------------------------------
+
+// Event is an occurrence of a custom event. User is identified by
+// ID, UserID or Email, in that order. CreatedAt defaults to now.
+// Metadata values are strings, numbers, booleans, time.Time,
+// MonetaryAmount or RichLink.
+type Event struct {
+	EventName string
+	CreatedAt time.Time
+	ID        string
+	UserID    string
+	Email     string
+	Metadata  map[string]interface{}
+}
+
+// MonetaryAmount is event metadata in cents of currency.
+type MonetaryAmount struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"` // ISO 4217, ie usd
+}
+
+// RichLink is event metadata rendered as link.
+type RichLink struct {
+	URL   string `json:"url"`
+	Value string `json:"value"`
+}
+
+// EventSummaries holds counts of user events by name.
+type EventSummaries struct {
+	Type           string         `json:"type"`
+	Email          string         `json:"email"`
+	IntercomUserID string         `json:"intercom_user_id"`
+	UserID         string         `json:"user_id"`
+	Events         []EventSummary `json:"events"`
+}
+
+type EventSummary struct {
+	Name        string    `json:"name"`
+	First       time.Time `json:"first"`
+	Last        time.Time `json:"last"`
+	Count       int64     `json:"count"`
+	Description string    `json:"description,omitempty"`
+}
+
+// EventSender delivers events.
+// Implemented by Intercom and EventRecorder.
+type EventSender interface {
+	TrackEvent(ev Event) error
+}
+
+//------------------------------------------------------------
+// Intercom methods: Events
+//------------------------------------------------------------
+
+// Sends single event.
+func (ic *Intercom) TrackEvent(ev Event) (err error) {
+
+	var req map[string]interface{}
+	if req, err = ev.payload(); err != nil {
+		return
+	}
+
+	_, err = ic.sendRequest("POST", API_EVENTS, nil, req)
+	return
+}
+
+// Retrieves event summaries of user identified by ID, UserID or Email.
+func (ic *Intercom) EventSummaries(user User) (summaries EventSummaries, err error) {
+
+	params := eventParams{Type: "user", Summary: true}
+	switch {
+	case user.ID != "":
+		params.IntercomUserID = user.ID
+	case user.UserID != "":
+		params.UserID = user.UserID
+	default:
+		params.Email = user.Email
+	}
+
+	var data []byte
+	data, err = ic.sendRequest("GET", API_EVENTS, params, nil)
+	if err != nil {
+		return
+	}
+
+	err = decode(data, &summaries)
+
+	return
+}
+
+//------------------------------------------------------------
+// Tracker
+//------------------------------------------------------------
+
+// Tracker buffers events and sends them in the background every
+// Interval or when Size events are pending. Failed events are
+// retried on next flush, up to MaxAttempts.
+type Tracker struct {
+	Sender      EventSender
+	Size        int
+	Interval    time.Duration
+	MaxPending  int // Track fails with ErrTrackerFull beyond
+	MaxAttempts int
+
+	// Called with events dropped after MaxAttempts.
+	OnError func(ev Event, err error)
+
+	mu      sync.Mutex
+	fmu     sync.Mutex // serializes Flush
+	pending []trackedEvent
+	kick    chan struct{}
+	stop    chan struct{}
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+type trackedEvent struct {
+	ev       Event
+	attempts int
+}
+
+func NewTracker(sender EventSender, size int, interval time.Duration) *Tracker {
+	return &Tracker{
+		Sender:      sender,
+		Size:        size,
+		Interval:    interval,
+		MaxPending:  10000,
+		MaxAttempts: 3,
+		kick:        make(chan struct{}, 1),
+	}
+}
+
+// Starts background sending.
+func (t *Tracker) Start() {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stop != nil || t.stopped {
+		return
+	}
+	t.stop = make(chan struct{})
+
+	t.wg.Add(1)
+	go func(stop chan struct{}) {
+		defer t.wg.Done()
+
+		interval := t.Interval
+		if interval <= 0 {
+			interval = defaultTrackerInterval
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			case <-t.kick:
+			}
+			t.Flush(context.Background())
+		}
+	}(t.stop)
+}
+
+// Queues event, never blocks on sending. Event time is fixed
+// when queued. Invalid events are rejected here, not retried.
+func (t *Tracker) Track(ev Event) error {
+
+	if ev.CreatedAt.IsZero() {
+		ev.CreatedAt = time.Now()
+	}
+
+	t.mu.Lock()
+	if t.stopped {
+		t.mu.Unlock()
+		return ErrTrackerStopped
+	}
+	if t.MaxPending > 0 && len(t.pending) >= t.MaxPending {
+		t.mu.Unlock()
+		return ErrTrackerFull
+	}
+	if _, err := ev.payload(); err != nil {
+		t.mu.Unlock()
+		return err
+	}
+	t.pending = append(t.pending, trackedEvent{ev: ev})
+	full := t.Size > 0 && len(t.pending) >= t.Size
+	t.mu.Unlock()
+
+	if full {
+		select {
+		case t.kick <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Sends all pending events, stopping early when ctx is done.
+// Returns the last send error, failed events stay queued.
+func (t *Tracker) Flush(ctx context.Context) (err error) {
+
+	t.fmu.Lock()
+	defer t.fmu.Unlock()
+
+	t.mu.Lock()
+	events := t.pending
+	t.pending = nil
+	t.mu.Unlock()
+
+	var retry []trackedEvent
+	for i, te := range events {
+		if ctx.Err() != nil {
+			retry = append(retry, events[i:]...)
+			err = ctx.Err()
+			break
+		}
+
+		sendErr := t.Sender.TrackEvent(te.ev)
+		if sendErr == nil {
+			continue
+		}
+		err = sendErr
+
+		te.attempts++
+		if t.MaxAttempts > 0 && te.attempts >= t.MaxAttempts {
+			if t.OnError != nil {
+				t.OnError(te.ev, sendErr)
+			}
+			continue
+		}
+		retry = append(retry, te)
+	}
+
+	if len(retry) > 0 {
+		t.mu.Lock()
+		t.pending = append(retry, t.pending...)
+		t.mu.Unlock()
+	}
+
+	return
+}
+
+// Number of events waiting to be sent.
+func (t *Tracker) Pending() int {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.pending)
+}
+
+// Stops background sending and flushes pending events until ctx
+// is done or they run out of attempts. Returns the last send error.
+// Track fails with ErrTrackerStopped afterwards.
+func (t *Tracker) Stop(ctx context.Context) error {
+
+	t.mu.Lock()
+	stop := t.stop
+	t.stop, t.stopped = nil, true
+	t.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		t.wg.Wait()
+	}
+
+	// retry failed events until done or out of attempts
+	rounds := t.MaxAttempts
+	if rounds < 1 {
+		rounds = 1
+	}
+
+	var err error
+	for i := 0; i < rounds && t.Pending() > 0; i++ {
+		if err = t.Flush(ctx); ctx.Err() != nil {
+			break
+		}
+	}
+
+	return err
+}
+
+//------------------------------------------------------------
+// EventRecorder
+//------------------------------------------------------------
+
+// EventRecorder is in-memory EventSender for tests.
+// Events are recorded instead of sent.
+type EventRecorder struct {
+	Events []Event
+	Err    error // returned by TrackEvent when set, nothing is recorded
+
+	mu sync.Mutex
+}
+
+func (rec *EventRecorder) TrackEvent(ev Event) error {
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if rec.Err != nil {
+		return rec.Err
+	}
+
+	rec.Events = append(rec.Events, ev)
+	return nil
+}
+
+//------------------------------------------------------------
+// Private methods
+//------------------------------------------------------------
+
+// Event as request payload.
+func (ev Event) payload() (m map[string]interface{}, err error) {
+
+	if ev.EventName == "" {
+		return nil, errors.New("Intercom event: missing event name")
+	}
+	if len(ev.Metadata) > maxEventMetadata {
+		return nil, fmt.Errorf("Intercom event %s: more than %d metadata keys", ev.EventName, maxEventMetadata)
+	}
+
+	createdAt := ev.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	m = map[string]interface{}{
+		"event_name": ev.EventName,
+		"created_at": createdAt.Unix(),
+	}
+
+	switch {
+	case ev.ID != "":
+		m["id"] = ev.ID
+	case ev.UserID != "":
+		m["user_id"] = ev.UserID
+	case ev.Email != "":
+		m["email"] = ev.Email
+	default:
+		return nil, errors.New("Intercom event: missing id, user_id or email")
+	}
+
+	if len(ev.Metadata) > 0 {
+		metadata := map[string]interface{}{}
+		for k, v := range ev.Metadata {
+			switch t := v.(type) {
+			case time.Time:
+				metadata[k] = t.Unix()
+			case *time.Time:
+				if t != nil {
+					metadata[k] = t.Unix()
+				}
+			default:
+				metadata[k] = v
+			}
+		}
+		m["metadata"] = metadata
+	}
+
+	return
+}
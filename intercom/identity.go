@@ -0,0 +1,151 @@
+package intercom
+
+/*
+
+example:
+
+m := intercom.Messenger{AppID: "abc123", Secret: "IDENTITY_VERIFICATION_SECRET"}
+
+// in page template
+snippet, err := m.Snippet(intercom.User{UserID: "42", Email: "jane@example.com", Name: "Jane"})
+
+// messenger security with JWT
+m.JWT = true
+m.JWTTTL = time.Hour
+settings, err := m.Settings(user)
+
+*/
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+//------------------------------------------------------------
+// Identity verification
+//------------------------------------------------------------
+
+// Computes user_hash: hex HMAC-SHA256 of user id, or email for
+// users without id, keyed with identity verification secret.
+func UserHash(secret, identifier string) string {
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(identifier))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Signs claims as HS256 JWT.
+func SignJWT(secret string, claims map[string]interface{}) (token string, err error) {
+
+	var payload []byte
+	if payload, err = json.Marshal(claims); err != nil {
+		return
+	}
+
+	enc := base64.RawURLEncoding
+	token = enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + enc.EncodeToString(payload)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(token))
+
+	return token + "." + enc.EncodeToString(mac.Sum(nil)), nil
+}
+
+//------------------------------------------------------------
+// Messenger
+//------------------------------------------------------------
+
+// Messenger builds boot settings of Intercom Messenger for
+// logged in users. Secret is identity verification secret,
+// with JWT set it signs intercom_user_jwt instead of user_hash.
+type Messenger struct {
+	AppID  string
+	Secret string
+	JWT    bool
+	JWTTTL time.Duration // JWT expiry, none when 0
+}
+
+// Boot settings for user: app_id, user_id, email, name, created_at,
+// custom attributes and user_hash or intercom_user_jwt.
+func (m Messenger) Settings(user User) (settings map[string]interface{}, err error) {
+
+	identifier := user.UserID
+	if identifier == "" {
+		identifier = user.Email
+	}
+	if identifier == "" {
+		return nil, errors.New("Intercom messenger: missing user_id or email")
+	}
+
+	settings = map[string]interface{}{}
+	for k, v := range user.CustomAttributes {
+		settings[k] = v
+	}
+
+	settings["app_id"] = m.AppID
+	if user.UserID != "" {
+		settings["user_id"] = user.UserID
+	}
+	if user.Email != "" {
+		settings["email"] = user.Email
+	}
+	if user.Name != "" {
+		settings["name"] = user.Name
+	}
+	if createdAt := user.SignedUpAt; createdAt != 0 {
+		settings["created_at"] = createdAt
+	}
+
+	if !m.JWT {
+		settings["user_hash"] = UserHash(m.Secret, identifier)
+		return
+	}
+
+	claims := map[string]interface{}{}
+	if user.UserID != "" {
+		claims["user_id"] = user.UserID
+	}
+	if user.Email != "" {
+		claims["email"] = user.Email
+	}
+	if m.JWTTTL > 0 {
+		claims["exp"] = time.Now().Add(m.JWTTTL).Unix()
+	}
+
+	var token string
+	if token, err = SignJWT(m.Secret, claims); err != nil {
+		return nil, err
+	}
+	settings["intercom_user_jwt"] = token
+
+	return
+}
+
+// Boot settings as JSON.
+func (m Messenger) JSON(user User) (data []byte, err error) {
+
+	var settings map[string]interface{}
+	if settings, err = m.Settings(user); err != nil {
+		return
+	}
+
+	return json.Marshal(settings)
+}
+
+// Boot settings as script setting window.intercomSettings, safe to
+// embed in HTML. Load the Messenger script after it.
+func (m Messenger) Snippet(user User) (snippet string, err error) {
+
+	var data []byte
+	if data, err = m.JSON(user); err != nil {
+		return
+	}
+
+	return "<script>window.intercomSettings = " + string(data) + ";</script>", nil
+}
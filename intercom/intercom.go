@@ -3,10 +3,12 @@ package intercom
 import (
 	"fmt"
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"net/http"
 	"io/ioutil"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-querystring/query"
@@ -18,6 +20,31 @@ import (
 
 type Intercom struct {
 	AccountKey string // account key
+	Version    string // Intercom-Version header, legacy API when empty or 1.x
+
+	// Wait for rate limit reset instead of failing with 429.
+	// Iterators always wait.
+	WaitOnRateLimit bool
+
+	BaseUrl    string       // optional, replaces https://api.intercom.io, ie for tests
+	HTTPClient *http.Client // optional, defaults to new client per request
+
+	state  *state     // shared by copies, nil for Intercom{} literals
+	callRL *RateLimit // set by WithRateLimit
+}
+
+// Mutable client state, kept behind pointer so Intercom
+// can be copied.
+type state struct {
+	mu        sync.Mutex
+	rateLimit RateLimit
+	cursors   map[string]pageCursor // list query and page -> cursor
+}
+
+// Cursor of a page of API 2.x listing by page number.
+type pageCursor struct {
+	cursor  string
+	savedAt time.Time
 }
 
 //------------------------------------------------------------
@@ -28,43 +55,66 @@ const (
 	API_USERS  = "https://api.intercom.io/users"
 	API_EVENTS = "https://api.intercom.io/events"
 	API_CONTACTS = "https://api.intercom.io/contacts"
+	API_CONTACTS_SEARCH = "https://api.intercom.io/contacts/search"
+	API_CONTACTS_MERGE  = "https://api.intercom.io/contacts/merge"
+	API_USERS_SCROLL    = "https://api.intercom.io/users/scroll"
+	API_COMPANIES       = "https://api.intercom.io/companies"
+	API_TAGS            = "https://api.intercom.io/tags"
+	API_SEGMENTS        = "https://api.intercom.io/segments"
+	API_MESSAGES        = "https://api.intercom.io/messages"
+	API_CONVERSATIONS   = "https://api.intercom.io/conversations"
+	API_CONTACTS_SCROLL = "https://api.intercom.io/contacts/scroll"
+
+	apiBaseUrl = "https://api.intercom.io"
+)
+
+// Intercom-Version of API 2.x, set as Version to opt in.
+const API_VERSION = "2.11"
+
+const (
+	// cached cursors of ListUsers and ListContacts pages
+	maxPageCursors = 1000
+	pageCursorTTL  = 10 * time.Minute
 )
 
 //------------------------------------------------------------
 // API
 //------------------------------------------------------------
 
+// Client of API 1.x, as before Version existed. Set Version
+// to API_VERSION for API 2.x.
 func NewIntercom(key string) Intercom {
-	return Intercom{AccountKey: key}
+	return Intercom{AccountKey: key, state: &state{}}
 }
 
 // Adds an event to a user.
+// See TrackEvent for other identifiers, timestamps and metadata types.
 func (ic *Intercom) CreateUserEvent(email, eventName string, metadata map[string]string) (err error) {
 
-	now := time.Now()
-	req := map[string]interface{}{
-		"event_name": eventName,
-		"email":      email,
-		"created_at": now.Unix(),
-		"metadata":   metadata,
+	ev := Event{
+		EventName: eventName,
+		Email:     email,
+		Metadata:  map[string]interface{}{},
+	}
+	for k, v := range metadata {
+		ev.Metadata[k] = v
 	}
 
-	_, err = ic.sendRequest("POST", API_EVENTS, nil, req)
-	return
+	return ic.TrackEvent(ev)
 }
 
 // Creates or updates a user.
+// On API 2.x users are contacts with role user.
+// See Users().Upsert for all user fields.
 func (ic *Intercom) UpsertUser(email, name, typ string) (err error) {
 
-	req := map[string]interface{}{
-		"email": email,
-		"name":  name,
-		"custom_attributes": map[string]string{
+	_, err = ic.Users().Upsert(User{
+		Email: email,
+		Name:  name,
+		CustomAttributes: map[string]interface{}{
 			"user_type": typ,
 		},
-	}
-
-	_, err = ic.sendRequest("POST", API_USERS, nil, req)
+	})
 	return
 }
 
@@ -73,8 +123,15 @@ func (ic *Intercom) UpsertUser(email, name, typ string) (err error) {
 // order - "asc", "desc"
 // sort - which field to sort by: 
 //        created_at, last_request_at, signed_up_at, updated_at
+// On API 2.x users are contacts with role user, found by search
+// with cursor pagination. Cursors are cached so looping pages in
+// order costs one request per page.
 func (ic *Intercom) ListUsers(page int64, order, sort string) (userList UserList, err error) {
 
+	if !ic.legacy() {
+		return ic.listUsersV2(page, order, sort)
+	}
+
 	params := UserListRequestParams{
 		Page: page,
 		Order: order,
@@ -90,10 +147,7 @@ func (ic *Intercom) ListUsers(page int64, order, sort string) (userList UserList
 	//s := string(data)
 	//fmt.Println(s)
 
-	err = json.Unmarshal(data, &userList)
-	if err != nil {
-		err = errors.New(string(data))
-	}
+	err = decode(data, &userList)
 
 	return
 }
@@ -101,6 +155,12 @@ func (ic *Intercom) ListUsers(page int64, order, sort string) (userList UserList
 // Archives user.
 func (ic *Intercom) ArchiveUser(user User) (user1 User, err error) {
 
+	if !ic.legacy() {
+		var contact Contact
+		contact, err = ic.Contacts().Archive(user.ID)
+		return contact.toUser(), err
+	}
+
 	url := fmt.Sprintf("%s/%s", API_USERS, user.ID)
 
 	var data []byte
@@ -112,10 +172,7 @@ func (ic *Intercom) ArchiveUser(user User) (user1 User, err error) {
 	//s := string(data)
 	//fmt.Println(s)
 
-	err = json.Unmarshal(data, &user1)
-	if err != nil {
-		err = errors.New(string(data))
-	}
+	err = decode(data, &user1)
 
 	return
 }
@@ -125,8 +182,13 @@ func (ic *Intercom) ArchiveUser(user User) (user1 User, err error) {
 // order - "asc", "desc"
 // sort - which field to sort by: 
 //        created_at, last_request_at, signed_up_at, updated_at
+// On API 2.x contacts with role lead are listed, see ListUsers.
 func (ic *Intercom) ListContacts(page int64, order, sort string) (contactList ContactList, err error) {
 
+	if !ic.legacy() {
+		return ic.listLeadsV2(page, order, sort)
+	}
+
 	params := UserListRequestParams{
 		Page: page,
 		Order: order,
@@ -142,10 +204,7 @@ func (ic *Intercom) ListContacts(page int64, order, sort string) (contactList Co
 	//s := string(data)
 	//fmt.Println(s)
 
-	err = json.Unmarshal(data, &contactList)
-	if err != nil {
-		err = errors.New(string(data))
-	}
+	err = decode(data, &contactList)
 
 	return
 }
@@ -154,6 +213,10 @@ func (ic *Intercom) ListContacts(page int64, order, sort string) (contactList Co
 // Archives contact.
 func (ic *Intercom) ArchiveContact(contact Contact) (contact1 Contact, err error) {
 
+	if !ic.legacy() {
+		return ic.Contacts().Archive(contact.ID)
+	}
+
 	url := fmt.Sprintf("%s/%s", API_CONTACTS, contact.ID)
 
 	var data []byte
@@ -165,10 +228,7 @@ func (ic *Intercom) ArchiveContact(contact Contact) (contact1 Contact, err error
 	//s := string(data)
 	//fmt.Println(s)
 
-	err = json.Unmarshal(data, &contact1)
-	if err != nil {
-		err = errors.New(string(data))
-	}
+	err = decode(data, &contact1)
 
 	return
 }
@@ -179,11 +239,23 @@ func (ic *Intercom) ArchiveContact(contact Contact) (contact1 Contact, err error
 
 // Sends request to Intercom.
 func (ic *Intercom) sendRequest(method, url string, queryParams interface{}, payload map[string]interface{}) (data []byte, err error) {
+	return ic.requestWait(context.Background(), method, url, queryParams, payload, ic.WaitOnRateLimit)
+}
+
+// Sends request to Intercom, returns response with body already read
+// for status and headers.
+func (ic *Intercom) request(ctx context.Context, method, url string, queryParams interface{}, payload map[string]interface{}) (data []byte, resp *http.Response, err error) {
+
+	client := ic.HTTPClient
+	if client == nil {
+		client = &http.Client{}
+	}
 
-	client := http.Client{}
+	if ic.BaseUrl != "" && strings.HasPrefix(url, apiBaseUrl) {
+		url = ic.BaseUrl + strings.TrimPrefix(url, apiBaseUrl)
+	}
 
 	var req *http.Request
-	var resp *http.Response
 
 	if payload != nil {
 		// With JSON payload
@@ -205,9 +277,13 @@ func (ic *Intercom) sendRequest(method, url string, queryParams interface{}, pay
 			return
 		}
 	}
+	req = req.WithContext(ctx)
 
 	req.SetBasicAuth(ic.AccountKey, "")
 	req.Header.Set("Accept", "application/json")
+	if ic.Version != "" {
+		req.Header.Set("Intercom-Version", ic.Version)
+	}
 
 	// Optional query parameters
 	if queryParams != nil {
@@ -226,18 +302,67 @@ func (ic *Intercom) sendRequest(method, url string, queryParams interface{}, pay
 	var err2 error
 	data, err2 = ioutil.ReadAll(resp.Body)
 	if err2 != nil {
-		return data, err2
+		return data, resp, err2
+	}
+
+	rl, ok := parseRateLimit(resp.Header)
+	if ok {
+		ic.setRateLimit(rl)
 	}
 
 	// Error returned?
 	if resp.StatusCode >= 400 {
-		s := string(data)
-		err = errors.New(s)
+		err = parseError(resp.StatusCode, data, rl)
 	}
 
 	return
 }
 
+// Closest page up to page with cached starting_after cursor for
+// list query key, page 1 with empty cursor when none.
+func (ic *Intercom) pageCursor(key string, page int64) (p int64, cursor string) {
+
+	if ic.state == nil {
+		return 1, ""
+	}
+
+	ic.state.mu.Lock()
+	defer ic.state.mu.Unlock()
+
+	now := time.Now()
+	for p = page; p > 1; p-- {
+		pc, ok := ic.state.cursors[fmt.Sprintf("%s|%d", key, p)]
+		if ok && now.Sub(pc.savedAt) < pageCursorTTL {
+			return p, pc.cursor
+		}
+	}
+
+	return 1, ""
+}
+
+// Caches starting_after cursor of page for list query key.
+func (ic *Intercom) savePageCursor(key string, page int64, cursor string) {
+
+	if ic.state == nil {
+		return
+	}
+
+	ic.state.mu.Lock()
+	defer ic.state.mu.Unlock()
+
+	// cache is small, drop it all instead of tracking usage
+	if ic.state.cursors == nil || len(ic.state.cursors) >= maxPageCursors {
+		ic.state.cursors = map[string]pageCursor{}
+	}
+
+	ic.state.cursors[fmt.Sprintf("%s|%d", key, page)] = pageCursor{cursor: cursor, savedAt: time.Now()}
+}
+
+// Tells if talking to API 1.x with separate users and leads.
+func (ic *Intercom) legacy() bool {
+	return ic.Version == "" || strings.HasPrefix(ic.Version, "1.")
+}
+
 func (ic *Intercom) addQueryParams(req *http.Request, params interface{}) {
 	v, _ := query.Values(params)
 	req.URL.RawQuery = v.Encode()
@@ -0,0 +1,284 @@
+package intercom
+
+/*
+
+example:
+
+it := ic.Users().All(ctx)
+for it.Next() {
+    user := it.User()
+    ...
+}
+if err := it.Err(); err != nil {
+    ...
+}
+
+*/
+
+import (
+	"context"
+)
+
+//------------------------------------------------------------
+// Constants
+//------------------------------------------------------------
+
+const (
+	// page size when iterating, API maximum
+	iterPerPage = 150
+)
+
+//------------------------------------------------------------
+// All
+//------------------------------------------------------------
+
+// Iterates over all users. Follows scroll param on API 1.x and
+// starting_after cursor on API 2.x, waiting out rate limits.
+// Iteration stops when ctx is done.
+func (us *Users) All(ctx context.Context) *UserIter {
+
+	ic := us.ic
+
+	if ic.legacy() {
+		return newUserIter(ctx, func(ctx context.Context, cursor string) (users []User, next string, err error) {
+
+			var data []byte
+			data, err = ic.requestPatient(ctx, "GET", API_USERS_SCROLL, scrollParams{ScrollParam: cursor}, nil)
+			if err != nil {
+				return
+			}
+
+			var userList UserList
+			if err = decode(data, &userList); err != nil {
+				return
+			}
+
+			// scroll ends with empty page
+			if len(userList.Users) > 0 {
+				next = userList.ScrollParam
+			}
+
+			return userList.Users, next, nil
+		})
+	}
+
+	return newUserIter(ctx, func(ctx context.Context, cursor string) (users []User, next string, err error) {
+
+		var cp ContactPage
+		cp, err = ic.searchPatient(ctx, map[string]interface{}{
+			"query": map[string]interface{}{
+				"field":    "role",
+				"operator": "=",
+				"value":    ROLE_USER,
+			},
+			"pagination": cursorPagination(cursor),
+		})
+		if err != nil {
+			return
+		}
+
+		for _, c := range cp.Data {
+			users = append(users, c.toUser())
+		}
+
+		return users, cp.StartingAfter(), nil
+	})
+}
+
+// Iterates over all contacts: users and leads on API 2.x, leads
+// on API 1.x. Waits out rate limits, stops when ctx is done.
+func (cs *Contacts) All(ctx context.Context) *ContactIter {
+
+	ic := cs.ic
+
+	if ic.legacy() {
+		return newContactIter(ctx, func(ctx context.Context, cursor string) (contacts []Contact, next string, err error) {
+
+			var data []byte
+			data, err = ic.requestPatient(ctx, "GET", API_CONTACTS_SCROLL, scrollParams{ScrollParam: cursor}, nil)
+			if err != nil {
+				return
+			}
+
+			var contactList ContactList
+			if err = decode(data, &contactList); err != nil {
+				return
+			}
+
+			// scroll ends with empty page
+			if len(contactList.Contacts) > 0 {
+				next = contactList.ScrollParam
+			}
+
+			return contactList.Contacts, next, nil
+		})
+	}
+
+	return newContactIter(ctx, func(ctx context.Context, cursor string) (contacts []Contact, next string, err error) {
+
+		var data []byte
+		data, err = ic.requestPatient(ctx, "GET", API_CONTACTS, cursorParams{PerPage: iterPerPage, StartingAfter: cursor}, nil)
+		if err != nil {
+			return
+		}
+
+		var cp ContactPage
+		if err = decode(data, &cp); err != nil {
+			return
+		}
+
+		return cp.Data, cp.StartingAfter(), nil
+	})
+}
+
+//------------------------------------------------------------
+// Iterators
+//------------------------------------------------------------
+
+// Fetch loop shared by iterators. Fetch loads page after cursor
+// into its iterator and returns page size and next cursor, empty
+// on last page.
+type pages struct {
+	ctx    context.Context
+	fetch  func(ctx context.Context, cursor string) (n int, next string, err error)
+	cursor string
+	left   int // items of current page not returned yet
+	done   bool
+	err    error
+}
+
+// Advances to the next item, fetching next page when needed.
+func (p *pages) next() bool {
+
+	for p.left == 0 {
+		if p.done || p.err != nil {
+			return false
+		}
+
+		if p.err = p.ctx.Err(); p.err != nil {
+			return false
+		}
+
+		if p.left, p.cursor, p.err = p.fetch(p.ctx, p.cursor); p.err != nil {
+			return false
+		}
+		p.done = p.cursor == ""
+	}
+
+	p.left--
+	return true
+}
+
+// UserIter iterates over users across all pages.
+type UserIter struct {
+	pages pages
+	items []User
+	cur   User
+}
+
+func newUserIter(ctx context.Context, fetch func(ctx context.Context, cursor string) ([]User, string, error)) *UserIter {
+
+	it := &UserIter{}
+	it.pages = pages{ctx: ctx, fetch: func(ctx context.Context, cursor string) (n int, next string, err error) {
+		it.items, next, err = fetch(ctx, cursor)
+		return len(it.items), next, err
+	}}
+
+	return it
+}
+
+// Advances to the next user, fetching next page when needed.
+// Returns false when done or on error, see Err.
+func (it *UserIter) Next() bool {
+
+	if !it.pages.next() {
+		return false
+	}
+
+	it.cur, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// Current user.
+func (it *UserIter) User() User {
+	return it.cur
+}
+
+// First error encountered while fetching pages, if any.
+func (it *UserIter) Err() error {
+	return it.pages.err
+}
+
+// ContactIter iterates over contacts across all pages.
+type ContactIter struct {
+	pages pages
+	items []Contact
+	cur   Contact
+}
+
+func newContactIter(ctx context.Context, fetch func(ctx context.Context, cursor string) ([]Contact, string, error)) *ContactIter {
+
+	it := &ContactIter{}
+	it.pages = pages{ctx: ctx, fetch: func(ctx context.Context, cursor string) (n int, next string, err error) {
+		it.items, next, err = fetch(ctx, cursor)
+		return len(it.items), next, err
+	}}
+
+	return it
+}
+
+// Advances to the next contact, fetching next page when needed.
+// Returns false when done or on error, see Err.
+func (it *ContactIter) Next() bool {
+
+	if !it.pages.next() {
+		return false
+	}
+
+	it.cur, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// Current contact.
+func (it *ContactIter) Contact() Contact {
+	return it.cur
+}
+
+// First error encountered while fetching pages, if any.
+func (it *ContactIter) Err() error {
+	return it.pages.err
+}
+
+//------------------------------------------------------------
+// Private methods
+//------------------------------------------------------------
+
+// Sends request, waiting out rate limits.
+func (ic *Intercom) requestPatient(ctx context.Context, method, url string, queryParams interface{}, payload map[string]interface{}) (data []byte, err error) {
+	return ic.requestWait(ctx, method, url, queryParams, payload, true)
+}
+
+// Sends contacts search request, waiting out rate limits.
+func (ic *Intercom) searchPatient(ctx context.Context, payload map[string]interface{}) (page ContactPage, err error) {
+
+	var data []byte
+	data, err = ic.requestPatient(ctx, "POST", API_CONTACTS_SEARCH, nil, payload)
+	if err != nil {
+		return
+	}
+
+	err = decode(data, &page)
+
+	return
+}
+
+// Search pagination starting after cursor.
+func cursorPagination(cursor string) map[string]interface{} {
+
+	pagination := map[string]interface{}{"per_page": iterPerPage}
+	if cursor != "" {
+		pagination["starting_after"] = cursor
+	}
+
+	return pagination
+}
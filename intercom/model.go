@@ -1,5 +1,7 @@
 package intercom
 
+import "encoding/json"
+
 //------------------------------------------------------------
 // Intercom data structures: API generic
 //------------------------------------------------------------
@@ -55,15 +57,146 @@ type User struct {
 	//SocialProfiles         *SocialProfileList     `json:"social_profiles,omitempty"`
 	UnsubscribedFromEmails *bool                  `json:"unsubscribed_from_emails,omitempty"`
 	UserAgentData          string                 `json:"user_agent_data,omitempty"`
-	//Tags                   *TagList               `json:"tags,omitempty"`
-	//Segments               *SegmentList           `json:"segments,omitempty"`
-	//Companies              *CompanyList           `json:"companies,omitempty"`
+	Tags                   *TagList               `json:"tags,omitempty"`
+	Segments               *SegmentList           `json:"segments,omitempty"`
+	Companies              *CompanyList           `json:"companies,omitempty"`
 	CustomAttributes       map[string]interface{} `json:"custom_attributes,omitempty"`
 	UpdateLastRequestAt    *bool                  `json:"update_last_request_at,omitempty"`
 	NewSession             *bool                  `json:"new_session,omitempty"`
 	LastSeenUserAgent      string                 `json:"last_seen_user_agent,omitempty"`
 }
 
+//------------------------------------------------------------
+// Intercom data structures: Companies
+//------------------------------------------------------------
+
+// CompanyList holds companies of a user or contact.
+// API 2.x contacts list only first companies, see HasMore.
+type CompanyList struct {
+	Type      string    `json:"type,omitempty"`
+	Companies []Company `json:"companies"`
+	HasMore   bool      `json:"has_more,omitempty"`
+}
+
+// Reads API 1.x companies or API 2.x data list.
+func (cl *CompanyList) UnmarshalJSON(b []byte) error {
+
+	var v struct {
+		Type      string    `json:"type"`
+		Companies []Company `json:"companies"`
+		Data      []Company `json:"data"`
+		HasMore   bool      `json:"has_more"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	cl.Type, cl.Companies, cl.HasMore = v.Type, v.Companies, v.HasMore
+	if cl.Companies == nil {
+		cl.Companies = v.Data
+	}
+
+	return nil
+}
+
+// Company represents a Company within Intercom, identified by
+// CompanyID when written.
+type Company struct {
+	Type             string                 `json:"type,omitempty"`
+	ID               string                 `json:"id,omitempty"`
+	CompanyID        string                 `json:"company_id,omitempty"`
+	Name             string                 `json:"name,omitempty"`
+	RemoteCreatedAt  int64                  `json:"remote_created_at,omitempty"`
+	CreatedAt        int64                  `json:"created_at,omitempty"`
+	UpdatedAt        int64                  `json:"updated_at,omitempty"`
+	LastRequestAt    int64                  `json:"last_request_at,omitempty"`
+	MonthlySpend     float64                `json:"monthly_spend,omitempty"`
+	SessionCount     int64                  `json:"session_count,omitempty"`
+	UserCount        int64                  `json:"user_count,omitempty"`
+	Size             int64                  `json:"size,omitempty"`
+	Website          string                 `json:"website,omitempty"`
+	Industry         string                 `json:"industry,omitempty"`
+	CustomAttributes map[string]interface{} `json:"custom_attributes,omitempty"`
+	Remove           bool                   `json:"-"` // detach from user on upsert
+}
+
+//------------------------------------------------------------
+// Intercom data structures: Tags and Segments
+//------------------------------------------------------------
+
+// TagList holds tags of a user, contact or workspace.
+// API 2.x contacts list only first tags, see HasMore.
+type TagList struct {
+	Type    string `json:"type,omitempty"`
+	Tags    []Tag  `json:"tags"`
+	HasMore bool   `json:"has_more,omitempty"`
+}
+
+// Reads API 1.x tags or API 2.x data list.
+func (tl *TagList) UnmarshalJSON(b []byte) error {
+
+	var v struct {
+		Type    string `json:"type"`
+		Tags    []Tag  `json:"tags"`
+		Data    []Tag  `json:"data"`
+		HasMore bool   `json:"has_more"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	tl.Type, tl.Tags, tl.HasMore = v.Type, v.Tags, v.HasMore
+	if tl.Tags == nil {
+		tl.Tags = v.Data
+	}
+
+	return nil
+}
+
+// Tag represents a Tag within Intercom.
+type Tag struct {
+	Type string `json:"type,omitempty"`
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// SegmentList holds segments of a user, contact or workspace.
+type SegmentList struct {
+	Type     string    `json:"type,omitempty"`
+	Segments []Segment `json:"segments"`
+}
+
+// Reads API 1.x segments or API 2.x data list.
+func (sl *SegmentList) UnmarshalJSON(b []byte) error {
+
+	var v struct {
+		Type     string    `json:"type"`
+		Segments []Segment `json:"segments"`
+		Data     []Segment `json:"data"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	sl.Type, sl.Segments = v.Type, v.Segments
+	if sl.Segments == nil {
+		sl.Segments = v.Data
+	}
+
+	return nil
+}
+
+// Segment represents a Segment within Intercom.
+type Segment struct {
+	Type       string `json:"type,omitempty"`
+	ID         string `json:"id,omitempty"`
+	Name       string `json:"name,omitempty"`
+	PersonType string `json:"person_type,omitempty"` // user, lead, contact
+	Count      int64  `json:"count,omitempty"`
+	CreatedAt  int64  `json:"created_at,omitempty"`
+	UpdatedAt  int64  `json:"updated_at,omitempty"`
+}
+
 //------------------------------------------------------------
 // Intercom data structures: Contact List
 //------------------------------------------------------------
@@ -76,32 +209,79 @@ type ContactList struct {
 }
 
 // Contact represents a Contact within Intercom.
+// On API 2.x both users and leads are contacts, told apart by Role.
 // Not all of the fields are writeable to the API, non-writeable fields are
 // stripped out from the request. Please see the API documentation for details.
 type Contact struct {
+	Type                   string                 `json:"type,omitempty"`
 	ID                     string                 `json:"id,omitempty"`
+	WorkspaceID            string                 `json:"workspace_id,omitempty"`
+	ExternalID             string                 `json:"external_id,omitempty"`
+	Role                   string                 `json:"role,omitempty"`
 	Email                  string                 `json:"email,omitempty"`
 	Phone                  string                 `json:"phone,omitempty"`
 	UserID                 string                 `json:"user_id,omitempty"`
 	Name                   string                 `json:"name,omitempty"`
 	//Avatar                 *UserAvatar            `json:"avatar,omitempty"`
 	//LocationData           *LocationData          `json:"location_data,omitempty"`
+	OwnerID                int64                  `json:"owner_id,omitempty"`
 	LastRequestAt          int64                  `json:"last_request_at,omitempty"`
+	SignedUpAt             int64                  `json:"signed_up_at,omitempty"`
+	LastSeenAt             int64                  `json:"last_seen_at,omitempty"`
+	LastRepliedAt          int64                  `json:"last_replied_at,omitempty"`
+	LastContactedAt        int64                  `json:"last_contacted_at,omitempty"`
 	CreatedAt              int64                  `json:"created_at,omitempty"`
 	UpdatedAt              int64                  `json:"updated_at,omitempty"`
 	SessionCount           int64                  `json:"session_count,omitempty"`
 	LastSeenIP             string                 `json:"last_seen_ip,omitempty"`
 	//SocialProfiles         *SocialProfileList     `json:"social_profiles,omitempty"`
 	UnsubscribedFromEmails *bool                  `json:"unsubscribed_from_emails,omitempty"`
+	HasHardBounced         *bool                  `json:"has_hard_bounced,omitempty"`
+	MarkedEmailAsSpam      *bool                  `json:"marked_email_as_spam,omitempty"`
+	Archived               bool                   `json:"archived,omitempty"`
 	UserAgentData          string                 `json:"user_agent_data,omitempty"`
-	//Tags                   *TagList               `json:"tags,omitempty"`
-	//Segments               *SegmentList           `json:"segments,omitempty"`
-	//Companies              *CompanyList           `json:"companies,omitempty"`
+	Tags                   *TagList               `json:"tags,omitempty"`
+	Segments               *SegmentList           `json:"segments,omitempty"`
+	Companies              *CompanyList           `json:"companies,omitempty"`
 	CustomAttributes       map[string]interface{} `json:"custom_attributes,omitempty"`
 	UpdateLastRequestAt    *bool                  `json:"update_last_request_at,omitempty"`
 	NewSession             *bool                  `json:"new_session,omitempty"`
 }
 
+//------------------------------------------------------------
+// Intercom data structures: API 2.x lists
+//------------------------------------------------------------
+
+// CursorPages holds paging information of API 2.x lists.
+type CursorPages struct {
+	Page       int64       `json:"page"`
+	PerPage    int64       `json:"per_page"`
+	TotalPages int64       `json:"total_pages"`
+	Next       *CursorNext `json:"next,omitempty"`
+}
+
+// CursorNext points to the next page.
+type CursorNext struct {
+	Page          int64  `json:"page,omitempty"`
+	StartingAfter string `json:"starting_after,omitempty"`
+}
+
+// ContactPage holds a page of API 2.x contacts.
+type ContactPage struct {
+	Type       string      `json:"type"`
+	Data       []Contact   `json:"data"`
+	TotalCount int64       `json:"total_count"`
+	Pages      CursorPages `json:"pages"`
+}
+
+// Cursor of the next page, empty on last page.
+func (cp *ContactPage) StartingAfter() string {
+	if cp.Pages.Next == nil {
+		return ""
+	}
+	return cp.Pages.Next.StartingAfter
+}
+
 type contactListParams struct {
 	PageParams
 	SegmentID string `url:"segment_id,omitempty"`
@@ -109,3 +289,34 @@ type contactListParams struct {
 	Email     string `url:"email,omitempty"`
 }
 
+
+// Request parameters: API 1.x scroll
+type scrollParams struct {
+	ScrollParam string `url:"scroll_param,omitempty"`
+}
+
+// Request parameters: API 2.x cursor list
+type cursorParams struct {
+	PerPage       int64  `url:"per_page,omitempty"`
+	StartingAfter string `url:"starting_after,omitempty"`
+}
+
+// Request parameters: user lookup
+type userParams struct {
+	UserID string `url:"user_id,omitempty"`
+	Email  string `url:"email,omitempty"`
+}
+
+// Request parameters: company lookup
+type companyParams struct {
+	CompanyID string `url:"company_id,omitempty"`
+}
+
+// Request parameters: event summaries
+type eventParams struct {
+	Type           string `url:"type"`
+	Summary        bool   `url:"summary,omitempty"`
+	IntercomUserID string `url:"intercom_user_id,omitempty"`
+	UserID         string `url:"user_id,omitempty"`
+	Email          string `url:"email,omitempty"`
+}
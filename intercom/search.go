@@ -0,0 +1,236 @@
+package intercom
+
+/*
+
+example: leads created in the last 7 days on plan pro
+
+q := intercom.And(
+    intercom.Eq("role", intercom.ROLE_LEAD),
+    intercom.Gt("created_at", time.Now().AddDate(0, 0, -7)),
+    intercom.Eq("custom_attributes.plan", "pro"),
+)
+
+it := ic.Contacts().SearchAll(ctx, intercom.SearchRequest{
+    Query: q,
+    Sort:  &intercom.Sort{Field: "created_at", Order: intercom.SORT_DESC},
+})
+for it.Next() {
+    contact := it.Contact()
+    ...
+}
+
+*/
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+//------------------------------------------------------------
+// Search model
+//------------------------------------------------------------
+
+// Search operators.
+const (
+	OP_EQ           = "="
+	OP_NE           = "!="
+	OP_IN           = "IN"
+	OP_NIN          = "NIN"
+	OP_LT           = "<"
+	OP_GT           = ">"
+	OP_CONTAINS     = "~"
+	OP_NOT_CONTAINS = "!~"
+	OP_STARTS_WITH  = "^"
+	OP_ENDS_WITH    = "$"
+	OP_AND          = "AND"
+	OP_OR           = "OR"
+)
+
+// Sort orders.
+const (
+	SORT_ASC  = "ascending"
+	SORT_DESC = "descending"
+)
+
+// Query is single field condition or AND/OR of nested queries.
+// Custom attributes are addressed as custom_attributes.name.
+// time.Time values are sent as unix time.
+type Query struct {
+	Field    string
+	Operator string
+	Value    interface{}
+	Queries  []Query // nested, Operator is AND or OR
+}
+
+// Sort of search results.
+type Sort struct {
+	Field string `json:"field"`
+	Order string `json:"order,omitempty"`
+}
+
+// Contacts search request, PerPage is 50 when not set, 150 at most.
+type SearchRequest struct {
+	Query         Query
+	Sort          *Sort
+	PerPage       int
+	StartingAfter string
+}
+
+//------------------------------------------------------------
+// Query builder
+//------------------------------------------------------------
+
+func Eq(field string, value interface{}) Query {
+	return Query{Field: field, Operator: OP_EQ, Value: value}
+}
+
+func Ne(field string, value interface{}) Query {
+	return Query{Field: field, Operator: OP_NE, Value: value}
+}
+
+func In(field string, values ...interface{}) Query {
+	return Query{Field: field, Operator: OP_IN, Value: values}
+}
+
+func Nin(field string, values ...interface{}) Query {
+	return Query{Field: field, Operator: OP_NIN, Value: values}
+}
+
+func Lt(field string, value interface{}) Query {
+	return Query{Field: field, Operator: OP_LT, Value: value}
+}
+
+func Gt(field string, value interface{}) Query {
+	return Query{Field: field, Operator: OP_GT, Value: value}
+}
+
+func Contains(field, value string) Query {
+	return Query{Field: field, Operator: OP_CONTAINS, Value: value}
+}
+
+func NotContains(field, value string) Query {
+	return Query{Field: field, Operator: OP_NOT_CONTAINS, Value: value}
+}
+
+func StartsWith(field, value string) Query {
+	return Query{Field: field, Operator: OP_STARTS_WITH, Value: value}
+}
+
+func EndsWith(field, value string) Query {
+	return Query{Field: field, Operator: OP_ENDS_WITH, Value: value}
+}
+
+// All queries must match.
+func And(queries ...Query) Query {
+	return Query{Operator: OP_AND, Queries: queries}
+}
+
+// Any query must match.
+func Or(queries ...Query) Query {
+	return Query{Operator: OP_OR, Queries: queries}
+}
+
+// Encodes query in Intercom search format.
+func (q Query) MarshalJSON() ([]byte, error) {
+
+	if q.Operator == OP_AND || q.Operator == OP_OR {
+		queries := q.Queries
+		if queries == nil {
+			queries = []Query{}
+		}
+		return json.Marshal(map[string]interface{}{
+			"operator": q.Operator,
+			"value":    queries,
+		})
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"field":    q.Field,
+		"operator": q.Operator,
+		"value":    queryValue(q.Value),
+	})
+}
+
+//------------------------------------------------------------
+// Search
+//------------------------------------------------------------
+
+// Searches contacts, one page per call. Use page StartingAfter
+// as req.StartingAfter to get next page. API 2.x only.
+func (cs *Contacts) Search(req SearchRequest) (page ContactPage, err error) {
+	return cs.search(req.payload())
+}
+
+// Iterates over all contacts matching request, waiting out rate limits.
+func (cs *Contacts) SearchAll(ctx context.Context, req SearchRequest) *ContactIter {
+
+	if req.PerPage == 0 {
+		req.PerPage = iterPerPage
+	}
+
+	it := newContactIter(ctx, func(ctx context.Context, cursor string) (contacts []Contact, next string, err error) {
+
+		req.StartingAfter = cursor
+
+		var cp ContactPage
+		if cp, err = cs.ic.searchPatient(ctx, req.payload()); err != nil {
+			return
+		}
+
+		return cp.Data, cp.StartingAfter(), nil
+	})
+	it.pages.cursor = req.StartingAfter
+
+	return it
+}
+
+//------------------------------------------------------------
+// Private methods
+//------------------------------------------------------------
+
+// Search request as payload.
+func (req SearchRequest) payload() map[string]interface{} {
+
+	m := map[string]interface{}{
+		"query": req.Query,
+	}
+
+	if req.Sort != nil {
+		m["sort"] = req.Sort
+	}
+
+	if req.PerPage > 0 || req.StartingAfter != "" {
+		pagination := map[string]interface{}{}
+		if req.PerPage > 0 {
+			pagination["per_page"] = req.PerPage
+		}
+		if req.StartingAfter != "" {
+			pagination["starting_after"] = req.StartingAfter
+		}
+		m["pagination"] = pagination
+	}
+
+	return m
+}
+
+// Converts times to unix time.
+func queryValue(v interface{}) interface{} {
+
+	switch t := v.(type) {
+	case time.Time:
+		return t.Unix()
+	case *time.Time:
+		if t != nil {
+			return t.Unix()
+		}
+	case []interface{}:
+		vs := make([]interface{}, len(t))
+		for i := range t {
+			vs[i] = queryValue(t[i])
+		}
+		return vs
+	}
+
+	return v
+}
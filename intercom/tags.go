@@ -0,0 +1,292 @@
+package intercom
+
+/*
+
+example:
+
+_, err := ic.Tags().TagUsers("beta", intercom.User{UserID: "42"}, intercom.User{Email: "jane@example.com"})
+...
+segments, err := ic.Segments().List()
+
+*/
+
+import (
+	"fmt"
+)
+
+//------------------------------------------------------------
+// Tags
+//------------------------------------------------------------
+
+// Tags is tags client.
+type Tags struct {
+	ic *Intercom
+}
+
+func (ic *Intercom) Tags() *Tags {
+	return &Tags{ic: ic}
+}
+
+// Lists all workspace tags.
+func (ts *Tags) List() (tags []Tag, err error) {
+
+	var data []byte
+	data, err = ts.ic.sendRequest("GET", API_TAGS, nil, nil)
+	if err != nil {
+		return
+	}
+
+	var tagList TagList
+	if err = decode(data, &tagList); err != nil {
+		return
+	}
+
+	return tagList.Tags, nil
+}
+
+// Creates tag, or renames it when ID is set.
+func (ts *Tags) Save(tag Tag) (tag1 Tag, err error) {
+
+	req := map[string]interface{}{"name": tag.Name}
+	if tag.ID != "" {
+		req["id"] = tag.ID
+	}
+
+	return ts.send(req)
+}
+
+// Deletes tag.
+func (ts *Tags) Delete(id string) (err error) {
+	_, err = ts.ic.sendRequest("DELETE", fmt.Sprintf("%s/%s", API_TAGS, id), nil, nil)
+	return
+}
+
+// Tags users, tag is created if missing.
+// Users are identified by ID, UserID or Email.
+func (ts *Tags) TagUsers(name string, users ...User) (tag Tag, err error) {
+	return ts.users(name, users, false)
+}
+
+// Removes tag from users, nothing is done if tag is missing.
+func (ts *Tags) UntagUsers(name string, users ...User) (tag Tag, err error) {
+	return ts.users(name, users, true)
+}
+
+// Tags companies, tag is created if missing.
+// Companies are identified by ID or CompanyID.
+func (ts *Tags) TagCompanies(name string, companies ...Company) (tag Tag, err error) {
+	return ts.companies(name, companies, false)
+}
+
+// Removes tag from companies, nothing is done if tag is missing.
+func (ts *Tags) UntagCompanies(name string, companies ...Company) (tag Tag, err error) {
+	return ts.companies(name, companies, true)
+}
+
+//------------------------------------------------------------
+// Segments
+//------------------------------------------------------------
+
+// Segments is segments client.
+type Segments struct {
+	ic *Intercom
+}
+
+func (ic *Intercom) Segments() *Segments {
+	return &Segments{ic: ic}
+}
+
+// Lists all workspace segments.
+func (ss *Segments) List() (segments []Segment, err error) {
+	return ss.list(API_SEGMENTS)
+}
+
+// Retrieves segment.
+func (ss *Segments) Get(id string) (segment Segment, err error) {
+
+	var data []byte
+	data, err = ss.ic.sendRequest("GET", fmt.Sprintf("%s/%s", API_SEGMENTS, id), nil, nil)
+	if err != nil {
+		return
+	}
+
+	err = decode(data, &segment)
+
+	return
+}
+
+// Lists segments user belongs to.
+// User is identified by ID, UserID or Email.
+func (ss *Segments) ForUser(user User) (segments []Segment, err error) {
+
+	ic := ss.ic
+
+	if !ic.legacy() {
+		var id string
+		if id, err = ic.Users().contactID(user); err != nil {
+			return
+		}
+		return ss.list(fmt.Sprintf("%s/%s/segments", API_CONTACTS, id))
+	}
+
+	// API 1.x returns segments with user
+	url, params := API_USERS, userParams{UserID: user.UserID, Email: user.Email}
+	if user.ID != "" {
+		url, params = fmt.Sprintf("%s/%s", API_USERS, user.ID), userParams{}
+	}
+
+	var data []byte
+	data, err = ic.sendRequest("GET", url, params, nil)
+	if err != nil {
+		return
+	}
+
+	var user1 User
+	if err = decode(data, &user1); err != nil {
+		return
+	}
+
+	if user1.Segments != nil {
+		segments = user1.Segments.Segments
+	}
+
+	return
+}
+
+//------------------------------------------------------------
+// Private methods
+//------------------------------------------------------------
+
+// Sends tag request returning single tag.
+func (ts *Tags) send(payload map[string]interface{}) (tag Tag, err error) {
+
+	var data []byte
+	data, err = ts.ic.sendRequest("POST", API_TAGS, nil, payload)
+	if err != nil {
+		return
+	}
+
+	err = decode(data, &tag)
+
+	return
+}
+
+// Tag named name, ok is false when there is none.
+func (ts *Tags) find(name string) (tag Tag, ok bool, err error) {
+
+	var tags []Tag
+	if tags, err = ts.List(); err != nil {
+		return
+	}
+
+	for _, t := range tags {
+		if t.Name == name {
+			return t, true, nil
+		}
+	}
+
+	return
+}
+
+func (ts *Tags) users(name string, users []User, untag bool) (tag Tag, err error) {
+
+	ic := ts.ic
+
+	// untagging never creates missing tag
+	if untag {
+		var ok bool
+		if tag, ok, err = ts.find(name); err != nil || !ok {
+			return
+		}
+	}
+
+	if ic.legacy() {
+		items := []map[string]interface{}{}
+		for _, u := range users {
+			item := map[string]interface{}{}
+			switch {
+			case u.ID != "":
+				item["id"] = u.ID
+			case u.UserID != "":
+				item["user_id"] = u.UserID
+			default:
+				item["email"] = u.Email
+			}
+			if untag {
+				item["untag"] = true
+			}
+			items = append(items, item)
+		}
+
+		return ts.send(map[string]interface{}{"name": name, "users": items})
+	}
+
+	// API 2.x tags contacts one by one
+	if !untag {
+		if tag, err = ts.Save(Tag{Name: name}); err != nil {
+			return
+		}
+	}
+
+	for _, u := range users {
+		var id string
+		if id, err = ic.Users().contactID(u); err != nil {
+			return
+		}
+
+		url := fmt.Sprintf("%s/%s/tags", API_CONTACTS, id)
+		if untag {
+			_, err = ic.sendRequest("DELETE", url+"/"+tag.ID, nil, nil)
+		} else {
+			_, err = ic.sendRequest("POST", url, nil, map[string]interface{}{"id": tag.ID})
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (ts *Tags) companies(name string, companies []Company, untag bool) (tag Tag, err error) {
+
+	if untag {
+		var ok bool
+		if tag, ok, err = ts.find(name); err != nil || !ok {
+			return
+		}
+	}
+
+	items := []map[string]interface{}{}
+	for _, c := range companies {
+		item := map[string]interface{}{}
+		if c.ID != "" {
+			item["id"] = c.ID
+		} else {
+			item["company_id"] = c.CompanyID
+		}
+		if untag {
+			item["untag"] = true
+		}
+		items = append(items, item)
+	}
+
+	return ts.send(map[string]interface{}{"name": name, "companies": items})
+}
+
+// Gets segment list at url.
+func (ss *Segments) list(url string) (segments []Segment, err error) {
+
+	var data []byte
+	data, err = ss.ic.sendRequest("GET", url, nil, nil)
+	if err != nil {
+		return
+	}
+
+	var segmentList SegmentList
+	if err = decode(data, &segmentList); err != nil {
+		return
+	}
+
+	return segmentList.Segments, nil
+}
@@ -0,0 +1,316 @@
+package intercom
+
+/*
+
+example:
+
+type Attrs struct {
+    Plan  string `json:"plan"`
+    Seats int    `json:"seats"`
+}
+
+attrs, err := intercom.CustomAttributesOf(Attrs{Plan: "pro", Seats: 5})
+...
+user, err := ic.Users().Upsert(intercom.User{
+    UserID:           "42",
+    Email:            "jane@example.com",
+    SignedUpAt:       signedUp.Unix(),
+    CustomAttributes: attrs,
+    Companies: &intercom.CompanyList{Companies: []intercom.Company{
+        {CompanyID: "acme", Name: "Acme"},
+    }},
+})
+
+*/
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+//------------------------------------------------------------
+// Users
+//------------------------------------------------------------
+
+// Users is users client, on API 2.x contacts with role user.
+type Users struct {
+	ic *Intercom
+}
+
+func (ic *Intercom) Users() *Users {
+	return &Users{ic: ic}
+}
+
+// Creates or updates user with all writable fields, read-only fields
+// are stripped. User is identified by ID, UserID or Email, in that
+// order. Companies are created as needed and attached, or detached
+// when Remove is set.
+func (us *Users) Upsert(user User) (user1 User, err error) {
+
+	ic := us.ic
+
+	if ic.legacy() {
+		var data []byte
+		data, err = ic.sendRequest("POST", API_USERS, nil, user.writable())
+		if err != nil {
+			return
+		}
+
+		err = decode(data, &user1)
+
+		return
+	}
+
+	cs := ic.Contacts()
+	contact := user.toContact()
+
+	var ok bool
+	if contact.ID, ok, err = us.find(user); err != nil {
+		return
+	}
+
+	if ok {
+		contact, err = cs.Update(contact)
+	} else {
+		contact, err = cs.Create(contact)
+	}
+	if err != nil {
+		return
+	}
+
+	user1 = contact.toUser()
+
+	if user.Companies != nil {
+		user1.Companies, err = ic.syncCompaniesV2(contact.ID, user.Companies.Companies)
+	}
+
+	return
+}
+
+// Converts struct to custom attributes using its json tags.
+// Intercom only accepts flat attributes: strings, numbers, booleans
+// and dates as unix time.
+func CustomAttributesOf(v interface{}) (attrs map[string]interface{}, err error) {
+
+	var data []byte
+	if data, err = json.Marshal(v); err != nil {
+		return
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err = dec.Decode(&attrs); err != nil {
+		return nil, err
+	}
+
+	for k, v := range attrs {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("Intercom custom attribute %s: nested values not supported", k)
+		}
+	}
+
+	return
+}
+
+//------------------------------------------------------------
+// Private methods
+//------------------------------------------------------------
+
+// Creates or updates companies, attaches them to contact or
+// detaches ones with Remove set, missing ones are skipped.
+// Returns attached companies.
+func (ic *Intercom) syncCompaniesV2(contactID string, companies []Company) (list *CompanyList, err error) {
+
+	list = &CompanyList{Type: "company.list"}
+
+	for _, company := range companies {
+		var company1 Company
+		if company.Remove {
+			// detaching never creates missing company
+			var ok bool
+			if company1, ok, err = ic.Companies().find(company.CompanyID); err != nil {
+				return
+			}
+			if !ok {
+				continue
+			}
+		} else if company1, err = ic.Companies().Upsert(company); err != nil {
+			return
+		}
+
+		url := fmt.Sprintf("%s/%s/companies", API_CONTACTS, contactID)
+		if company.Remove {
+			_, err = ic.sendRequest("DELETE", url+"/"+company1.ID, nil, nil)
+		} else {
+			_, err = ic.sendRequest("POST", url, nil, map[string]interface{}{"id": company1.ID})
+			list.Companies = append(list.Companies, company1)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// API 2.x contact id of user identified by ID, UserID or Email,
+// ok is false when there is none. User not found by UserID is
+// looked up by Email, in case it was created without one.
+func (us *Users) find(user User) (id string, ok bool, err error) {
+
+	if user.ID != "" {
+		return user.ID, true, nil
+	}
+
+	var contact Contact
+	cs := us.ic.Contacts()
+	switch {
+	case user.UserID != "":
+		if contact, ok, err = cs.FindByExternalID(user.UserID); err != nil || ok || user.Email == "" {
+			break
+		}
+
+		// user created by email only, update sets external_id;
+		// one with other external_id is another user
+		if contact, ok, err = cs.FindByEmail(user.Email, ROLE_USER); ok && contact.ExternalID != "" {
+			contact, ok = Contact{}, false
+		}
+	case user.Email != "":
+		contact, ok, err = cs.FindByEmail(user.Email, ROLE_USER)
+	default:
+		err = errors.New("Intercom user: missing id, user_id or email")
+	}
+
+	return contact.ID, ok, err
+}
+
+// API 1.x user identified by ID, UserID or Email, ok is false
+// when there is none.
+func (us *Users) findLegacy(user User) (user1 User, ok bool, err error) {
+
+	if user.ID == "" && user.UserID == "" && user.Email == "" {
+		err = errors.New("Intercom user: missing id, user_id or email")
+		return
+	}
+
+	url, params := API_USERS, userParams{UserID: user.UserID, Email: user.Email}
+	if user.ID != "" {
+		url, params = fmt.Sprintf("%s/%s", API_USERS, user.ID), userParams{}
+	}
+
+	var data []byte
+	data, err = us.ic.sendRequest("GET", url, params, nil)
+
+	var ierr *ErrorList
+	if errors.As(err, &ierr) && ierr.StatusCode == http.StatusNotFound {
+		return User{}, false, nil
+	}
+	if err != nil {
+		return
+	}
+
+	if err = decode(data, &user1); err != nil {
+		return
+	}
+
+	return user1, true, nil
+}
+
+// API 2.x contact id of existing user.
+func (us *Users) contactID(user User) (id string, err error) {
+
+	var ok bool
+	if id, ok, err = us.find(user); err == nil && !ok {
+		err = fmt.Errorf("Intercom user not found: %s%s", user.UserID, user.Email)
+	}
+
+	return
+}
+
+// Writable fields of API 1.x user as request payload.
+func (u User) writable() map[string]interface{} {
+
+	m := map[string]interface{}{}
+	set := func(k string, v interface{}, ok bool) {
+		if ok {
+			m[k] = v
+		}
+	}
+
+	set("id", u.ID, u.ID != "")
+	set("user_id", u.UserID, u.UserID != "")
+	set("email", u.Email, u.Email != "")
+	set("phone", u.Phone, u.Phone != "")
+	set("name", u.Name, u.Name != "")
+	set("signed_up_at", u.SignedUpAt, u.SignedUpAt != 0)
+	set("remote_created_at", u.RemoteCreatedAt, u.RemoteCreatedAt != 0)
+	set("last_request_at", u.LastRequestAt, u.LastRequestAt != 0)
+	set("last_seen_ip", u.LastSeenIP, u.LastSeenIP != "")
+	set("last_seen_user_agent", u.LastSeenUserAgent, u.LastSeenUserAgent != "")
+	set("unsubscribed_from_emails", u.UnsubscribedFromEmails, u.UnsubscribedFromEmails != nil)
+	set("update_last_request_at", u.UpdateLastRequestAt, u.UpdateLastRequestAt != nil)
+	set("new_session", u.NewSession, u.NewSession != nil)
+	set("custom_attributes", u.CustomAttributes, len(u.CustomAttributes) > 0)
+
+	if u.Companies != nil && len(u.Companies.Companies) > 0 {
+		companies := []map[string]interface{}{}
+		for _, c := range u.Companies.Companies {
+			cm := c.writable()
+			if c.Remove {
+				cm["remove"] = true
+			}
+			companies = append(companies, cm)
+		}
+		m["companies"] = companies
+	}
+
+	return m
+}
+
+// Writable fields of company as request payload.
+func (c Company) writable() map[string]interface{} {
+
+	m := map[string]interface{}{}
+	set := func(k string, v interface{}, ok bool) {
+		if ok {
+			m[k] = v
+		}
+	}
+
+	set("company_id", c.CompanyID, c.CompanyID != "")
+	set("name", c.Name, c.Name != "")
+	set("remote_created_at", c.RemoteCreatedAt, c.RemoteCreatedAt != 0)
+	set("monthly_spend", c.MonthlySpend, c.MonthlySpend != 0)
+	set("size", c.Size, c.Size != 0)
+	set("website", c.Website, c.Website != "")
+	set("industry", c.Industry, c.Industry != "")
+	set("custom_attributes", c.CustomAttributes, len(c.CustomAttributes) > 0)
+
+	return m
+}
+
+// Converts API 1.x user to API 2.x contact with role user.
+func (u User) toContact() Contact {
+
+	signedUpAt := u.SignedUpAt
+	if signedUpAt == 0 {
+		signedUpAt = u.RemoteCreatedAt
+	}
+
+	return Contact{
+		ID:                     u.ID,
+		Role:                   ROLE_USER,
+		ExternalID:             u.UserID,
+		Email:                  u.Email,
+		Phone:                  u.Phone,
+		Name:                   u.Name,
+		SignedUpAt:             signedUpAt,
+		LastSeenAt:             u.LastRequestAt,
+		UnsubscribedFromEmails: u.UnsubscribedFromEmails,
+		CustomAttributes:       u.CustomAttributes,
+	}
+}
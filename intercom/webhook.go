@@ -0,0 +1,201 @@
+package intercom
+
+/*
+
+example:
+
+wh := intercom.NewWebhookHandler("CLIENT_SECRET")
+wh.On(intercom.TOPIC_CONVERSATION_USER_CREATED, func(n intercom.Notification) error {
+    conv, err := n.Conversation()
+    ...
+    return nil
+})
+
+http.Handle("/intercom/webhook", wh)
+
+*/
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------
+// Webhook model
+//------------------------------------------------------------
+
+// Webhook topics, not exhaustive.
+const (
+	TOPIC_PING                          = "ping"
+	TOPIC_CONVERSATION_USER_CREATED     = "conversation.user.created"
+	TOPIC_CONVERSATION_USER_REPLIED     = "conversation.user.replied"
+	TOPIC_CONVERSATION_ADMIN_REPLIED    = "conversation.admin.replied"
+	TOPIC_CONVERSATION_ADMIN_ASSIGNED   = "conversation.admin.assigned"
+	TOPIC_CONVERSATION_ADMIN_CLOSED     = "conversation.admin.closed"
+	TOPIC_CONVERSATION_ADMIN_NOTED      = "conversation.admin.noted"
+	TOPIC_CONTACT_CREATED               = "contact.created"
+	TOPIC_CONTACT_USER_CREATED          = "contact.user.created"
+	TOPIC_CONTACT_LEAD_CREATED          = "contact.lead.created"
+	TOPIC_CONTACT_DELETED               = "contact.deleted"
+	TOPIC_CONTACT_TAG_CREATED           = "contact.tag.created"
+	TOPIC_USER_CREATED                  = "user.created"
+	TOPIC_USER_DELETED                  = "user.deleted"
+	TOPIC_USER_EMAIL_UPDATED            = "user.email.updated"
+	TOPIC_USER_UNSUBSCRIBED             = "user.unsubscribed"
+	TOPIC_COMPANY_CREATED               = "company.created"
+	TOPIC_CONVERSATION_PART_TAG_CREATED = "conversation_part.tag.created"
+)
+
+// Largest notification body accepted.
+const maxWebhookBody = 1 << 20
+
+// Notification is webhook notification envelope.
+// Data.Item holds topic payload, see typed accessors.
+type Notification struct {
+	Type             string           `json:"type"`
+	ID               string           `json:"id"`
+	AppID            string           `json:"app_id"`
+	Topic            string           `json:"topic"`
+	DeliveryStatus   string           `json:"delivery_status,omitempty"`
+	DeliveryAttempts int              `json:"delivery_attempts"`
+	DeliveredAt      int64            `json:"delivered_at"`
+	FirstSentAt      int64            `json:"first_sent_at"`
+	CreatedAt        int64            `json:"created_at"`
+	Data             NotificationData `json:"data"`
+}
+
+type NotificationData struct {
+	Type string          `json:"type"`
+	Item json.RawMessage `json:"item"`
+}
+
+// Time the notification was created.
+func (n Notification) Time() time.Time {
+	return time.Unix(n.CreatedAt, 0)
+}
+
+// Decodes payload into v.
+func (n Notification) Decode(v interface{}) error {
+	return json.Unmarshal(n.Data.Item, v)
+}
+
+// Payload of conversation.* topics.
+func (n Notification) Conversation() (conv Conversation, err error) {
+	err = n.Decode(&conv)
+	return
+}
+
+// Payload of contact.* topics.
+func (n Notification) Contact() (contact Contact, err error) {
+	err = n.Decode(&contact)
+	return
+}
+
+// Payload of user.* topics.
+func (n Notification) User() (user User, err error) {
+	err = n.Decode(&user)
+	return
+}
+
+// Payload of company.* topics.
+func (n Notification) Company() (company Company, err error) {
+	err = n.Decode(&company)
+	return
+}
+
+// Handles notifications of one topic. Returning error responds 500
+// and Intercom retries the notification, the error is not sent.
+type WebhookFunc func(n Notification) error
+
+//------------------------------------------------------------
+// WebhookHandler
+//------------------------------------------------------------
+
+// WebhookHandler is http.Handler receiving Intercom notifications
+// signed with X-Hub-Signature. Without ClientSecret every request
+// is rejected.
+type WebhookHandler struct {
+	ClientSecret string // app client secret
+
+	mu       sync.RWMutex
+	handlers map[string]WebhookFunc
+}
+
+func NewWebhookHandler(clientSecret string) *WebhookHandler {
+	return &WebhookHandler{
+		ClientSecret: clientSecret,
+		handlers:     map[string]WebhookFunc{},
+	}
+}
+
+// Registers callback for topic, ie "contact.created".
+// Notifications of topics without callback are acknowledged and dropped.
+func (wh *WebhookHandler) On(topic string, fn WebhookFunc) {
+
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+
+	if wh.handlers == nil {
+		wh.handlers = map[string]WebhookFunc{}
+	}
+	wh.handlers[topic] = fn
+}
+
+func (wh *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if wh.ClientSecret == "" {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		http.Error(w, "cannot read body", http.StatusBadRequest)
+		return
+	}
+
+	expected := "sha1=" + Signature(wh.ClientSecret, body)
+	if !hmac.Equal([]byte(expected), []byte(r.Header.Get("X-Hub-Signature"))) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var n Notification
+	if err = json.Unmarshal(body, &n); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	wh.mu.RLock()
+	fn := wh.handlers[n.Topic]
+	wh.mu.RUnlock()
+
+	if fn != nil {
+		if err = fn(n); err != nil {
+			http.Error(w, "notification not processed", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+//------------------------------------------------------------
+// Signature
+//------------------------------------------------------------
+
+// Computes notification signature: hex HMAC-SHA1 of body keyed
+// with client secret. Sent as "sha1=" + signature.
+func Signature(clientSecret string, body []byte) string {
+
+	mac := hmac.New(sha1.New, []byte(clientSecret))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,712 @@
+package alienplugs
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deze333/alienplugs/intercom"
+)
+
+func TestIntercomSearchQuery(t *testing.T) {
+
+	since := time.Unix(1700000000, 0)
+	q := intercom.And(
+		intercom.Eq("role", intercom.ROLE_LEAD),
+		intercom.Gt("created_at", since),
+		intercom.Or(
+			intercom.Eq("custom_attributes.plan", "pro"),
+			intercom.In("custom_attributes.plan", "team", "enterprise"),
+		),
+	)
+
+	data, err := json.Marshal(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"operator":"AND","value":[` +
+		`{"field":"role","operator":"=","value":"lead"},` +
+		`{"field":"created_at","operator":">","value":1700000000},` +
+		`{"operator":"OR","value":[` +
+		`{"field":"custom_attributes.plan","operator":"=","value":"pro"},` +
+		`{"field":"custom_attributes.plan","operator":"IN","value":["team","enterprise"]}]}]}`
+
+	var got, want interface{}
+	json.Unmarshal(data, &got)
+	json.Unmarshal([]byte(expected), &want)
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected query:\n%s\nexpected:\n%s", data, expected)
+	}
+}
+
+func TestIntercomCustomAttributesOf(t *testing.T) {
+
+	type attrs struct {
+		Plan    string  `json:"plan"`
+		Seats   int     `json:"seats"`
+		Trial   bool    `json:"trial"`
+		Revenue float64 `json:"revenue,omitempty"`
+	}
+
+	m, err := intercom.CustomAttributesOf(attrs{Plan: "pro", Seats: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := json.Marshal(m)
+	if string(data) != `{"plan":"pro","seats":5,"trial":false}` {
+		t.Fatalf("Unexpected attributes: %s", data)
+	}
+
+	if _, err = intercom.CustomAttributesOf(map[string]interface{}{"tags": []string{"a"}}); err == nil {
+		t.Fatal("Expected error for nested value")
+	}
+}
+
+func TestIntercomTagLists(t *testing.T) {
+
+	// API 1.x user and API 2.x contact
+	var user intercom.User
+	json.Unmarshal([]byte(`{"tags":{"type":"tag.list","tags":[{"type":"tag","id":"1","name":"beta"}]}}`), &user)
+
+	var contact intercom.Contact
+	json.Unmarshal([]byte(`{"tags":{"type":"list","data":[{"type":"tag","id":"1"}],"has_more":true}}`), &contact)
+
+	if user.Tags == nil || len(user.Tags.Tags) != 1 || user.Tags.Tags[0].Name != "beta" {
+		t.Fatalf("Unexpected user tags: %+v", user.Tags)
+	}
+	if contact.Tags == nil || len(contact.Tags.Tags) != 1 || contact.Tags.Tags[0].ID != "1" || !contact.Tags.HasMore {
+		t.Fatalf("Unexpected contact tags: %+v", contact.Tags)
+	}
+}
+
+func TestIntercomTracker(t *testing.T) {
+
+	rec := &intercom.EventRecorder{}
+	tracker := intercom.NewTracker(rec, 2, time.Hour)
+	tracker.Start()
+
+	for _, name := range []string{"a", "b", "c"} {
+		if err := tracker.Track(intercom.Event{EventName: name, UserID: "42"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tracker.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Events) != 3 || rec.Events[0].CreatedAt.IsZero() {
+		t.Fatalf("Unexpected events: %+v", rec.Events)
+	}
+	if err := tracker.Track(intercom.Event{EventName: "d"}); err != intercom.ErrTrackerStopped {
+		t.Fatalf("Expected ErrTrackerStopped, got %v", err)
+	}
+
+	// invalid events are rejected, not queued
+	tracker = intercom.NewTracker(rec, 10, time.Hour)
+	if err := tracker.Track(intercom.Event{EventName: "d"}); err == nil || tracker.Pending() != 0 {
+		t.Fatalf("Expected event without user rejected, got %v, pending %d", err, tracker.Pending())
+	}
+	if err := tracker.Track(intercom.Event{UserID: "42"}); err == nil || tracker.Pending() != 0 {
+		t.Fatalf("Expected event without name rejected, got %v, pending %d", err, tracker.Pending())
+	}
+
+	// failed events are dropped after MaxAttempts
+	rec = &intercom.EventRecorder{Err: errors.New("boom")}
+	dropped := 0
+	tracker = intercom.NewTracker(rec, 10, time.Hour)
+	tracker.OnError = func(ev intercom.Event, err error) { dropped++ }
+	tracker.Track(intercom.Event{EventName: "a", UserID: "42"})
+
+	tracker.Flush(context.Background())
+	if tracker.Pending() != 1 {
+		t.Fatalf("Expected failed event to be requeued, pending %d", tracker.Pending())
+	}
+
+	if err := tracker.Stop(context.Background()); err == nil {
+		t.Fatal("Expected send error")
+	}
+	if tracker.Pending() != 0 || dropped != 1 {
+		t.Fatalf("Expected event dropped, pending %d dropped %d", tracker.Pending(), dropped)
+	}
+}
+
+func TestIntercomWebhook(t *testing.T) {
+
+	var got []intercom.Conversation
+	wh := intercom.NewWebhookHandler("secret")
+	wh.On(intercom.TOPIC_CONVERSATION_USER_CREATED, func(n intercom.Notification) error {
+		conv, err := n.Conversation()
+		got = append(got, conv)
+		return err
+	})
+
+	body := `{"type":"notification_event","id":"notif_1","topic":"conversation.user.created","created_at":1700000000,` +
+		`"data":{"type":"notification_event_data","item":{"type":"conversation","id":"147","open":true,` +
+		`"user":{"type":"user","id":"5310d8e7","user_id":"42"}}}}`
+
+	send := func(body, secret string) int {
+		r := httptest.NewRequest("POST", "/intercom/webhook", strings.NewReader(body))
+		r.Header.Set("X-Hub-Signature", "sha1="+intercom.Signature(secret, []byte(body)))
+		w := httptest.NewRecorder()
+		wh.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := send(body, "wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("Expected bad signature rejected, got %d", code)
+	}
+	if code := send(body, "secret"); code != http.StatusOK {
+		t.Fatalf("Expected notification accepted, got %d", code)
+	}
+	if code := send(`{"type":"notification_event","topic":"ping","data":{"item":{}}}`, "secret"); code != http.StatusOK {
+		t.Fatalf("Expected ping accepted, got %d", code)
+	}
+
+	if len(got) != 1 || got[0].ID != "147" || !got[0].Open || got[0].User == nil || got[0].User.UserID != "42" {
+		t.Fatalf("Unexpected conversations routed: %+v", got)
+	}
+
+	// Handler errors are not echoed
+	wh.On(intercom.TOPIC_PING, func(n intercom.Notification) error {
+		return errors.New("db password expired")
+	})
+	r := httptest.NewRequest("POST", "/intercom/webhook", strings.NewReader(`{"topic":"ping"}`))
+	r.Header.Set("X-Hub-Signature", "sha1="+intercom.Signature("secret", []byte(`{"topic":"ping"}`)))
+	w := httptest.NewRecorder()
+	wh.ServeHTTP(w, r)
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "password") {
+		t.Fatalf("Unexpected handler error response: %d %q", w.Code, w.Body.String())
+	}
+
+	// Oversized body is rejected
+	big := `{"topic":"ping","pad":"` + strings.Repeat("x", 2<<20) + `"}`
+	if code := send(big, "secret"); code != http.StatusBadRequest {
+		t.Fatalf("Expected oversized body rejected, got %d", code)
+	}
+
+	// Without secret, even a matching empty-key signature is rejected
+	wh.ClientSecret = ""
+	if code := send(body, ""); code != http.StatusUnauthorized {
+		t.Fatalf("Expected request rejected without secret, got %d", code)
+	}
+	if len(got) != 1 {
+		t.Fatalf("Notification routed without secret: %+v", got)
+	}
+}
+
+func TestIntercomIdentity(t *testing.T) {
+
+	if h := intercom.UserHash("key", "The quick brown fox jumps over the lazy dog"); h != "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8" {
+		t.Fatalf("Unexpected user hash: %s", h)
+	}
+
+	m := intercom.Messenger{AppID: "abc123", Secret: "secret"}
+	user := intercom.User{UserID: "42", Email: "jane@example.com", CustomAttributes: map[string]interface{}{"plan": "</script>"}}
+
+	settings, err := m.Settings(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings["user_hash"] != intercom.UserHash("secret", "42") || settings["plan"] != "</script>" || settings["app_id"] != "abc123" {
+		t.Fatalf("Unexpected settings: %v", settings)
+	}
+
+	snippet, _ := m.Snippet(user)
+	if strings.Count(snippet, "</script>") != 1 {
+		t.Fatalf("Snippet not escaped: %s", snippet)
+	}
+
+	// JWT signed with secret over header and claims
+	m.JWT = true
+	settings, _ = m.Settings(user)
+	token, _ := settings["intercom_user_jwt"].(string)
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || settings["user_hash"] != nil {
+		t.Fatalf("Unexpected JWT settings: %v", settings)
+	}
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if base64.RawURLEncoding.EncodeToString(mac.Sum(nil)) != parts[2] {
+		t.Fatal("Invalid JWT signature")
+	}
+
+	claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	if string(claims) != `{"email":"jane@example.com","user_id":"42"}` {
+		t.Fatalf("Unexpected JWT claims: %s", claims)
+	}
+}
+
+func TestIntercomErrorList(t *testing.T) {
+
+	var ierr *intercom.ErrorList
+	if err := json.Unmarshal([]byte(`{"type":"error.list","request_id":"req1",`+
+		`"errors":[{"code":"parameter_invalid","message":"Email invalid","field":"email"}]}`), &ierr); err != nil {
+		t.Fatal(err)
+	}
+	ierr.StatusCode = 400
+
+	err := fmt.Errorf("upsert failed: %w", ierr)
+
+	var got *intercom.ErrorList
+	if !errors.As(err, &got) || got.RequestID != "req1" || !got.Has("parameter_invalid") || got.Has("not_found") {
+		t.Fatalf("Unexpected error list: %+v", got)
+	}
+	if got.Error() != "Intercom error 400: parameter_invalid: Email invalid (email)" {
+		t.Fatalf("Unexpected message: %s", got.Error())
+	}
+}
+
+func TestIntercomRequestErrors(t *testing.T) {
+
+	reset := time.Now().Add(time.Hour).Unix()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "1000")
+		w.Header().Set("X-RateLimit-Remaining", "998")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+
+		switch r.URL.Path {
+		case "/contacts/missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"type":"error.list","request_id":"req2","errors":[{"code":"not_found","message":"User Not Found"}]}`))
+		case "/contacts/proxy":
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("upstream down\n"))
+		default:
+			w.Write([]byte(`{"type":"contact","id":"c1"}`))
+		}
+	}))
+	defer srv.Close()
+
+	ic := intercom.NewIntercom("key")
+	ic.BaseUrl = srv.URL
+	ic.Version = intercom.API_VERSION
+
+	// Copies share rate limit state, per call rate limit via WithRateLimit
+	var rl intercom.RateLimit
+	ic2 := ic
+	if c, err := ic2.WithRateLimit(&rl).Contacts().Get("c1"); err != nil || c.ID != "c1" {
+		t.Fatalf("Unexpected contact: %+v, %v", c, err)
+	}
+	if rl.Limit != 1000 || rl.Remaining != 998 || rl.Reset.Unix() != reset || ic.RateLimit() != rl {
+		t.Fatalf("Unexpected rate limit: %+v, shared %+v", rl, ic.RateLimit())
+	}
+
+	var ierr *intercom.ErrorList
+	_, err := ic.Contacts().Get("missing")
+	if !errors.As(err, &ierr) || ierr.StatusCode != 404 || !ierr.Has("not_found") || ierr.RequestID != "req2" || ierr.RateLimit.Remaining != 998 {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Non-JSON body kept as message
+	_, err = ic.Contacts().Get("proxy")
+	if !errors.As(err, &ierr) || ierr.StatusCode != 502 || ierr.Error() != "Intercom error 502: upstream down" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestIntercomRateLimitWait(t *testing.T) {
+
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-RateLimit-Limit", "2")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+
+		switch calls {
+		case 1:
+			// window exhausted, next request waits for reset
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix()+1, 10))
+			w.Write([]byte(`{"type":"contact","id":"c1"}`))
+		case 2:
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"type":"error.list","errors":[{"code":"rate_limit_exceeded","message":"Exceeded rate limit"}]}`))
+		default:
+			w.Header().Set("X-RateLimit-Remaining", "1")
+			w.Write([]byte(`{"type":"contact","id":"c1"}`))
+		}
+	}))
+	defer srv.Close()
+
+	ic := intercom.NewIntercom("key")
+	ic.BaseUrl = srv.URL
+	ic.Version = intercom.API_VERSION
+
+	// Without waiting 429 is returned
+	ic.Contacts().Get("c1")
+	var ierr *intercom.ErrorList
+	if _, err := ic.Contacts().Get("c1"); !errors.As(err, &ierr) || !ierr.Has("rate_limit_exceeded") {
+		t.Fatalf("Expected rate limit error, got %v", err)
+	}
+
+	// Waits out exhausted window, then retries 429
+	calls = 0
+	ic.WaitOnRateLimit = true
+	ic.Contacts().Get("c1")
+
+	start := time.Now()
+	if _, err := ic.Contacts().Get("c1"); err != nil || calls != 3 {
+		t.Fatalf("Expected retry after 429, %d calls, %v", calls, err)
+	}
+	if d := time.Since(start); d < 2*time.Second {
+		t.Fatalf("Expected wait for exhausted window and 429, waited %v", d)
+	}
+}
+
+func TestIntercomContactPaging(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/contacts" || r.Header.Get("Intercom-Version") != intercom.API_VERSION {
+			http.NotFound(w, r)
+			return
+		}
+
+		switch r.URL.Query().Get("starting_after") {
+		case "":
+			w.Write([]byte(`{"type":"list","data":[{"id":"c1"},{"id":"c2"}],"pages":{"next":{"starting_after":"WzJd"}}}`))
+		case "WzJd":
+			w.Write([]byte(`{"type":"list","data":[{"id":"c3"}],"pages":{}}`))
+		}
+	}))
+	defer srv.Close()
+
+	ic := intercom.NewIntercom("key")
+	ic.BaseUrl = srv.URL
+	ic.Version = intercom.API_VERSION
+
+	var ids []string
+	it := ic.Contacts().All(context.Background())
+	for it.Next() {
+		ids = append(ids, it.Contact().ID)
+	}
+	if it.Err() != nil || strings.Join(ids, ",") != "c1,c2,c3" {
+		t.Fatalf("Unexpected contacts: %v, %v", ids, it.Err())
+	}
+
+	// Canceled context stops iteration
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if it = ic.Contacts().All(ctx); it.Next() || it.Err() != context.Canceled {
+		t.Fatalf("Expected context error, got %v", it.Err())
+	}
+}
+
+func TestIntercomListUsersPages(t *testing.T) {
+
+	searches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		searches++
+
+		var req struct {
+			Pagination struct {
+				StartingAfter string `json:"starting_after"`
+			} `json:"pagination"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		// cursor "pN" points to page N
+		page := 1
+		if req.Pagination.StartingAfter != "" {
+			page, _ = strconv.Atoi(strings.TrimPrefix(req.Pagination.StartingAfter, "p"))
+		}
+		fmt.Fprintf(w, `{"type":"list","data":[{"id":"c%d","role":"user"}],"pages":{"next":{"starting_after":"p%d"}}}`, page, page+1)
+	}))
+	defer srv.Close()
+
+	ic := intercom.NewIntercom("key")
+	ic.BaseUrl = srv.URL
+	ic.Version = intercom.API_VERSION
+
+	for page := int64(1); page <= 4; page++ {
+		list, err := ic.ListUsers(page, "desc", "created_at")
+		if err != nil || len(list.Users) != 1 || list.Users[0].ID != fmt.Sprintf("c%d", page) {
+			t.Fatalf("Unexpected page %d: %+v, %v", page, list, err)
+		}
+	}
+	if searches != 4 {
+		t.Fatalf("Expected one request per page, got %d", searches)
+	}
+
+	// Other sort walks from first page
+	if _, err := ic.ListUsers(2, "asc", "created_at"); err != nil || searches != 6 {
+		t.Fatalf("Expected walk from first page, got %d requests, %v", searches, err)
+	}
+}
+
+func TestIntercomListUsersLegacy(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.Method != "GET" || r.URL.Path != "/users" || r.Header.Get("Intercom-Version") != "" {
+			http.NotFound(w, r)
+			return
+		}
+		if q.Get("order") != "desc" || q.Get("sort") != "created_at" {
+			t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+		}
+		fmt.Fprintf(w, `{"type":"user.list","pages":{"page":%s,"total_pages":3},"users":[{"id":"u%s","user_id":"42"}]}`, q.Get("page"), q.Get("page"))
+	}))
+	defer srv.Close()
+
+	// NewIntercom keeps API 1.x
+	ic := intercom.NewIntercom("key")
+	ic.BaseUrl = srv.URL
+
+	list, err := ic.ListUsers(2, "desc", "created_at")
+	if err != nil || len(list.Users) != 1 || list.Users[0].ID != "u2" || list.Users[0].UserID != "42" {
+		t.Fatalf("Unexpected page: %+v, %v", list, err)
+	}
+}
+
+func TestIntercomUpsertUserByEmail(t *testing.T) {
+
+	var updated map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		switch {
+		case r.URL.Path == "/contacts/search" && strings.Contains(string(body), `"external_id"`):
+			w.Write([]byte(`{"type":"list","data":[]}`))
+		case r.URL.Path == "/contacts/search":
+			w.Write([]byte(`{"type":"list","data":[{"type":"contact","id":"c1","role":"user","email":"jane@example.com"}]}`))
+		case r.Method == "PUT" && r.URL.Path == "/contacts/c1":
+			json.Unmarshal(body, &updated)
+			w.Write([]byte(`{"type":"contact","id":"c1","role":"user","external_id":"42","email":"jane@example.com"}`))
+		default:
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"type":"error.list","errors":[{"code":"conflict","message":"A contact matching those details already exists"}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	ic := intercom.NewIntercom("key")
+	ic.BaseUrl = srv.URL
+	ic.Version = intercom.API_VERSION
+
+	// User created by email only gets external_id on update
+	user, err := ic.Users().Upsert(intercom.User{UserID: "42", Email: "jane@example.com", Name: "Jane"})
+	if err != nil || user.ID != "c1" || user.UserID != "42" {
+		t.Fatalf("Unexpected user: %+v, %v", user, err)
+	}
+	if updated["external_id"] != "42" || updated["name"] != "Jane" {
+		t.Fatalf("Unexpected update: %v", updated)
+	}
+}
+
+func TestIntercomRemoveMissing(t *testing.T) {
+
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/companies":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"type":"error.list","errors":[{"code":"company_not_found","message":"Company Not Found"}]}`))
+		case r.Method == "GET" && r.URL.Path == "/tags":
+			w.Write([]byte(`{"type":"list","data":[{"type":"tag","id":"t1","name":"beta"}]}`))
+		case r.Method == "DELETE" && r.URL.Path == "/contacts/c1/tags/t1":
+			w.Write([]byte(`{"type":"tag","id":"t1","name":"beta"}`))
+		default:
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	ic := intercom.NewIntercom("key")
+	ic.BaseUrl = srv.URL
+	ic.Version = intercom.API_VERSION
+	jane := intercom.User{ID: "c1"}
+
+	// Missing company and tag are not created
+	if err := ic.Companies().RemoveUser("acme", jane); err != nil {
+		t.Fatal(err)
+	}
+	if tag, err := ic.Tags().UntagUsers("gone", jane); err != nil || tag.ID != "" {
+		t.Fatalf("Unexpected untag: %+v, %v", tag, err)
+	}
+	if tag, err := ic.Tags().UntagUsers("beta", jane); err != nil || tag.ID != "t1" {
+		t.Fatalf("Unexpected untag: %+v, %v", tag, err)
+	}
+
+	expected := "GET /companies,GET /tags,GET /tags,DELETE /contacts/c1/tags/t1"
+	if strings.Join(requests, ",") != expected {
+		t.Fatalf("Unexpected requests: %v", requests)
+	}
+}
+
+func TestIntercomRemoveMissingLegacy(t *testing.T) {
+
+	var requests []string
+	var upserted map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		q := r.URL.Query()
+
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/users" && q.Get("email") == "jane@example.com":
+			w.Write([]byte(`{"type":"user","id":"u1","email":"jane@example.com"}`))
+		case r.Method == "GET" && r.URL.Path == "/users":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"type":"error.list","errors":[{"code":"not_found","message":"User Not Found"}]}`))
+		case r.Method == "GET" && r.URL.Path == "/companies" && q.Get("company_id") == "acme":
+			w.Write([]byte(`{"type":"company","id":"co1","company_id":"acme"}`))
+		case r.Method == "GET" && r.URL.Path == "/companies":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"type":"error.list","errors":[{"code":"company_not_found","message":"Company Not Found"}]}`))
+		case r.Method == "POST" && r.URL.Path == "/users":
+			json.NewDecoder(r.Body).Decode(&upserted)
+			w.Write([]byte(`{"type":"user","id":"u1"}`))
+		default:
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	// API 1.x
+	ic := intercom.NewIntercom("key")
+	ic.BaseUrl = srv.URL
+
+	// Missing user or company is not created
+	if err := ic.Companies().RemoveUser("acme", intercom.User{Email: "nobody@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ic.Companies().RemoveUser("gone", intercom.User{Email: "jane@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if upserted != nil {
+		t.Fatalf("Unexpected upsert: %v", upserted)
+	}
+
+	// Existing user is detached by id
+	if err := ic.Companies().RemoveUser("acme", intercom.User{Email: "jane@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	companies, _ := json.Marshal(upserted["companies"])
+	if upserted["id"] != "u1" || string(companies) != `[{"company_id":"acme","remove":true}]` {
+		t.Fatalf("Unexpected upsert: %v", upserted)
+	}
+
+	expected := "GET /users,GET /users,GET /companies,GET /users,GET /companies,POST /users"
+	if strings.Join(requests, ",") != expected {
+		t.Fatalf("Unexpected requests: %v", requests)
+	}
+}
+
+func TestIntercomConversationParties(t *testing.T) {
+
+	var sent []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		switch r.URL.Path {
+		case "/contacts/search":
+			w.Write([]byte(`{"type":"list","data":[{"type":"contact","id":"c1","role":"user","external_id":"42"}]}`))
+		case "/messages", "/conversations":
+			sent = append(sent, body)
+			w.Write([]byte(`{"type":"admin_message","id":"m1","conversation_id":"147"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ic := intercom.NewIntercom("key")
+	ic.BaseUrl = srv.URL
+	ic.Version = intercom.API_VERSION
+
+	// API 2.x takes contact id only
+	if _, err := ic.Conversations().Message(intercom.AdminMessage{Body: "Hi", AdminID: "7", To: intercom.Party{UserID: "42"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ic.Conversations().Create(intercom.Party{Type: "user", Email: "jane@example.com"}, "Help"); err != nil {
+		t.Fatal(err)
+	}
+
+	// API 1.x writes ExternalID as user_id
+	ic.Version = "1.4"
+	if _, err := ic.Conversations().Create(intercom.Party{Type: "user", ExternalID: "42"}, "Help"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{`{"id":"c1","type":"user"}`, `{"id":"c1","type":"user"}`, `{"type":"user","user_id":"42"}`}
+	for i, body := range sent {
+		key := "from"
+		if i == 0 {
+			key = "to"
+		}
+		if data, _ := json.Marshal(body[key]); string(data) != expected[i] {
+			t.Fatalf("Unexpected party %d: %s", i, data)
+		}
+	}
+	if len(sent) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(sent))
+	}
+
+	// Lead needs id or email
+	ic.Version = intercom.API_VERSION
+	if _, err := ic.Conversations().Create(intercom.Party{Type: "lead"}, "Help"); err == nil {
+		t.Fatal("Expected error for lead without id or email")
+	}
+	if len(sent) != 3 {
+		t.Fatalf("Unexpected message sent: %v", sent[len(sent)-1])
+	}
+}
+
+func TestIntercomConversationPaging(t *testing.T) {
+
+	broken := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case broken:
+			w.Write([]byte(`{"type":"conversation.list","pages":"broken","conversations":[{"id":"1"}]}`))
+		case r.URL.Query().Get("page") == "2":
+			w.Write([]byte(`{"type":"conversation.list","pages":{"page":2,"total_pages":2},"conversations":[{"id":"2"}]}`))
+		default:
+			w.Write([]byte(`{"type":"conversation.list","pages":{"page":1,"total_pages":2},"conversations":[{"id":"1"}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	// API 1.x pages by number
+	ic := intercom.NewIntercom("key")
+	ic.BaseUrl = srv.URL
+
+	var ids []string
+	it := ic.Conversations().All(context.Background())
+	for it.Next() {
+		ids = append(ids, it.Conversation().ID)
+	}
+	if it.Err() != nil || strings.Join(ids, ",") != "1,2" {
+		t.Fatalf("Unexpected conversations: %v, %v", ids, it.Err())
+	}
+
+	// Malformed pages are an error, not a silent last page
+	broken = true
+	it = ic.Conversations().All(context.Background())
+	for it.Next() {
+	}
+	if it.Err() == nil {
+		t.Fatal("Expected error for malformed pages")
+	}
+}
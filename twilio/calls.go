@@ -0,0 +1,224 @@
+package twilio
+
+/*
+
+example:
+
+call, err := tc.Call("+XXXXX", "<Response><Say>Incident P1</Say></Response>", &twilio.CallOpts{
+    Timeout: 30,
+    MachineDetection: "Enable",
+})
+
+if err != nil {
+    fmt.Print(err) // log error
+} else {
+    fmt.Print(call.Sid, call.Status) // queued
+}
+
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//------------------------------------------------------------
+// Call
+//------------------------------------------------------------
+
+// Call represents a Twilio call resource.
+type Call struct {
+	Sid         string `json:"sid"`
+	AccountSid  string `json:"account_sid"`
+	To          string `json:"to"`
+	From        string `json:"from"`
+	Status      string `json:"status"`
+	Direction   string `json:"direction"`
+	AnsweredBy  string `json:"answered_by"`
+	Duration    string `json:"duration"`
+	Price       string `json:"price"`
+	PriceUnit   string `json:"price_unit"`
+	DateCreated string `json:"date_created"`
+	DateUpdated string `json:"date_updated"`
+	StartTime   string `json:"start_time"`
+	EndTime     string `json:"end_time"`
+	Uri         string `json:"uri"`
+}
+
+// CallOpts holds optional parameters of an outbound call.
+// Zero values are not sent to Twilio.
+type CallOpts struct {
+	StatusCallback       string   // url notified on call status changes
+	StatusCallbackMethod string   // "POST" (default) or "GET"
+	StatusCallbackEvents []string // initiated, ringing, answered, completed
+	MachineDetection     string   // "Enable" or "DetectMessageEnd"
+	Timeout              int      // seconds to let the phone ring
+}
+
+// CallFilter narrows down ListCalls results.
+// Zero values are not sent to Twilio.
+type CallFilter struct {
+	To            string
+	From          string
+	Status        string    // queued, ringing, in-progress, completed, busy, failed, no-answer, canceled
+	StartedAfter  time.Time // inclusive day
+	StartedBefore time.Time // inclusive day
+	PageSize      int
+}
+
+type callPage struct {
+	pageMeta
+	Calls []Call `json:"calls"`
+}
+
+// CallIter iterates over calls across all result pages.
+type CallIter struct {
+	pager pager
+	items []Call
+	cur   Call
+}
+
+//------------------------------------------------------------
+// TwilioCfg methods: Calls
+//------------------------------------------------------------
+
+// Places outbound call to phone.
+// twimlOrUrl is either TwiML instructions to execute on answer
+// or http(s) url of TwiML document. opts may be nil.
+func (tc *TwilioCfg) Call(toPhone, twimlOrUrl string, opts *CallOpts) (call *Call, err error) {
+
+	form := url.Values{
+		"To": {
+			E164Phone(toPhone),
+		},
+		"From": {
+			tc.FromPhone,
+		},
+	}
+
+	if isUrl(twimlOrUrl) {
+		form.Set("Url", twimlOrUrl)
+	} else {
+		form.Set("Twiml", twimlOrUrl)
+	}
+
+	if opts != nil {
+		opts.addTo(form)
+	}
+
+	var data []byte
+	data, err = tc.sendRequest("POST", fmt.Sprintf(apiCallUrl, tc.AccountSID), form)
+	if err != nil {
+		return
+	}
+
+	call = &Call{}
+	if err = json.Unmarshal(data, call); err != nil {
+		err = fmt.Errorf("twilio call response: %v", err)
+		call = nil
+	}
+
+	return
+}
+
+// Lists calls matching filter, newest first.
+// Pages are fetched lazily as the iterator advances.
+func (tc *TwilioCfg) ListCalls(filter CallFilter) *CallIter {
+	return &CallIter{
+		pager: pager{
+			tc:    tc,
+			url:   fmt.Sprintf(apiCallUrl, tc.AccountSID),
+			query: filter.values(),
+		},
+	}
+}
+
+//------------------------------------------------------------
+// CallIter methods
+//------------------------------------------------------------
+
+// Advances to the next call, fetching next page when needed.
+// Returns false when done or on error, see Err.
+func (it *CallIter) Next() bool {
+
+	for len(it.items) == 0 {
+		var pg callPage
+		if !it.pager.next(&pg) {
+			return false
+		}
+		it.items = pg.Calls
+	}
+
+	it.cur, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// Current call.
+func (it *CallIter) Call() Call {
+	return it.cur
+}
+
+// First error encountered while fetching pages, if any.
+func (it *CallIter) Err() error {
+	return it.pager.err
+}
+
+//------------------------------------------------------------
+// Private methods
+//------------------------------------------------------------
+
+// Converts filter into Twilio query parameters.
+func (f CallFilter) values() url.Values {
+
+	v := url.Values{}
+	if f.To != "" {
+		v.Set("To", E164Phone(f.To))
+	}
+	if f.From != "" {
+		v.Set("From", f.From)
+	}
+	if f.Status != "" {
+		v.Set("Status", f.Status)
+	}
+	if !f.StartedAfter.IsZero() {
+		v.Set("StartTime>", f.StartedAfter.Format(dateFormat))
+	}
+	if !f.StartedBefore.IsZero() {
+		v.Set("StartTime<", f.StartedBefore.Format(dateFormat))
+	}
+	if f.PageSize > 0 {
+		v.Set("PageSize", strconv.Itoa(f.PageSize))
+	}
+
+	return v
+}
+
+// Adds non-empty options to request form.
+func (opts *CallOpts) addTo(form url.Values) {
+
+	if opts.StatusCallback != "" {
+		form.Set("StatusCallback", opts.StatusCallback)
+	}
+	if opts.StatusCallbackMethod != "" {
+		form.Set("StatusCallbackMethod", opts.StatusCallbackMethod)
+	}
+	for _, ev := range opts.StatusCallbackEvents {
+		form.Add("StatusCallbackEvent", ev)
+	}
+	if opts.MachineDetection != "" {
+		form.Set("MachineDetection", opts.MachineDetection)
+	}
+	if opts.Timeout > 0 {
+		form.Set("Timeout", strconv.Itoa(opts.Timeout))
+	}
+}
+
+// Tells if s looks like http(s) url rather than TwiML.
+func isUrl(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
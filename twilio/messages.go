@@ -0,0 +1,192 @@
+package twilio
+
+/*
+
+example:
+
+it := tc.ListMessages(twilio.MessageFilter{
+    DateSentAfter: time.Now().AddDate(0, -1, 0),
+})
+
+for it.Next() {
+    msg := it.Message()
+    fmt.Print(msg.Sid, msg.To, msg.Price)
+}
+
+if err := it.Err(); err != nil {
+    fmt.Print(err) // log error
+}
+
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+//------------------------------------------------------------
+// Message
+//------------------------------------------------------------
+
+// Message represents a Twilio message resource.
+type Message struct {
+	Sid          string `json:"sid"`
+	AccountSid   string `json:"account_sid"`
+	To           string `json:"to"`
+	From         string `json:"from"`
+	Body         string `json:"body"`
+	Status       string `json:"status"`
+	Direction    string `json:"direction"`
+	NumSegments  string `json:"num_segments"`
+	Price        string `json:"price"`
+	PriceUnit    string `json:"price_unit"`
+	ErrorCode    *int   `json:"error_code"`
+	ErrorMessage string `json:"error_message"`
+	DateCreated  string `json:"date_created"`
+	DateSent     string `json:"date_sent"`
+	DateUpdated  string `json:"date_updated"`
+	Uri          string `json:"uri"`
+}
+
+// MessageFilter narrows down ListMessages results.
+// Zero values are not sent to Twilio.
+type MessageFilter struct {
+	To             string
+	From           string
+	DateSent       time.Time // exact day
+	DateSentAfter  time.Time // inclusive day
+	DateSentBefore time.Time // inclusive day
+	PageSize       int
+}
+
+type messagePage struct {
+	pageMeta
+	Messages []Message `json:"messages"`
+}
+
+// MessageIter iterates over messages across all result pages.
+type MessageIter struct {
+	pager pager
+	items []Message
+	cur   Message
+}
+
+//------------------------------------------------------------
+// TwilioCfg methods: Messages
+//------------------------------------------------------------
+
+// Lists messages matching filter, newest first.
+// Pages are fetched lazily as the iterator advances.
+func (tc *TwilioCfg) ListMessages(filter MessageFilter) *MessageIter {
+	return &MessageIter{
+		pager: pager{
+			tc:    tc,
+			url:   fmt.Sprintf(apiMsgUrl, tc.AccountSID),
+			query: filter.values(),
+		},
+	}
+}
+
+// Retrieves message by sid.
+func (tc *TwilioCfg) GetMessage(sid string) (msg *Message, err error) {
+
+	var data []byte
+	data, err = tc.sendRequest("GET", fmt.Sprintf(apiMsgItemUrl, tc.AccountSID, sid), nil)
+	if err != nil {
+		return
+	}
+
+	return decodeMessage(data)
+}
+
+// Redacts message body by sid, keeping the message record.
+// Used to honour privacy requests.
+func (tc *TwilioCfg) RedactMessage(sid string) (msg *Message, err error) {
+
+	form := url.Values{
+		"Body": {
+			"",
+		},
+	}
+
+	var data []byte
+	data, err = tc.sendRequest("POST", fmt.Sprintf(apiMsgItemUrl, tc.AccountSID, sid), form)
+	if err != nil {
+		return
+	}
+
+	return decodeMessage(data)
+}
+
+//------------------------------------------------------------
+// MessageIter methods
+//------------------------------------------------------------
+
+// Advances to the next message, fetching next page when needed.
+// Returns false when done or on error, see Err.
+func (it *MessageIter) Next() bool {
+
+	for len(it.items) == 0 {
+		var pg messagePage
+		if !it.pager.next(&pg) {
+			return false
+		}
+		it.items = pg.Messages
+	}
+
+	it.cur, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// Current message.
+func (it *MessageIter) Message() Message {
+	return it.cur
+}
+
+// First error encountered while fetching pages, if any.
+func (it *MessageIter) Err() error {
+	return it.pager.err
+}
+
+//------------------------------------------------------------
+// Private methods
+//------------------------------------------------------------
+
+// Converts filter into Twilio query parameters.
+func (f MessageFilter) values() url.Values {
+
+	v := url.Values{}
+	if f.To != "" {
+		v.Set("To", E164Phone(f.To))
+	}
+	if f.From != "" {
+		v.Set("From", f.From)
+	}
+	if !f.DateSent.IsZero() {
+		v.Set("DateSent", f.DateSent.Format(dateFormat))
+	}
+	if !f.DateSentAfter.IsZero() {
+		v.Set("DateSent>", f.DateSentAfter.Format(dateFormat))
+	}
+	if !f.DateSentBefore.IsZero() {
+		v.Set("DateSent<", f.DateSentBefore.Format(dateFormat))
+	}
+	if f.PageSize > 0 {
+		v.Set("PageSize", strconv.Itoa(f.PageSize))
+	}
+
+	return v
+}
+
+func decodeMessage(data []byte) (msg *Message, err error) {
+
+	msg = &Message{}
+	if err = json.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("twilio message response: %v", err)
+	}
+
+	return
+}
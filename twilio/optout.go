@@ -0,0 +1,329 @@
+package twilio
+
+/*
+
+example:
+
+optOuts, err := twilio.NewFileOptOuts("/var/lib/app/sms_optouts.txt")
+...
+tc.OptOuts = optOuts
+
+// inbound messages webhook registers STOP/START replies,
+// requests are verified with tc.AuthToken
+http.Handle("/twilio/inbound", tc.InboundHandler(nil))
+
+err = tc.SMS("+XXXXX", "message")
+if err == twilio.ErrOptedOut {
+    // recipient replied STOP, nothing sent
+}
+
+*/
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//------------------------------------------------------------
+// Errors
+//------------------------------------------------------------
+
+// Returned by SMS when recipient has opted out.
+var ErrOptedOut = errors.New("twilio: recipient opted out of messages")
+
+//------------------------------------------------------------
+// Keywords
+//------------------------------------------------------------
+
+var (
+	optOutKeywords = []string{"STOP", "STOPALL", "UNSUBSCRIBE", "CANCEL", "END", "QUIT"}
+	optInKeywords  = []string{"START", "YES", "UNSTOP"}
+)
+
+//------------------------------------------------------------
+// OptOutRegistry
+//------------------------------------------------------------
+
+// OptOutRegistry remembers phones that must not be texted.
+// Phones are passed as given, implementations compare digits only.
+type OptOutRegistry interface {
+	IsOptedOut(phone string) (bool, error)
+	OptOut(phone string) error
+	OptIn(phone string) error
+}
+
+// Registers opt-out or opt-in when inbound message body is one of
+// the compliance keywords. Returns matched keyword, if any.
+func RegisterKeyword(reg OptOutRegistry, fromPhone, body string) (keyword string, err error) {
+
+	word := strings.ToUpper(strings.TrimSpace(body))
+
+	if contains(optOutKeywords, word) {
+		return word, reg.OptOut(fromPhone)
+	}
+	if contains(optInKeywords, word) {
+		return word, reg.OptIn(fromPhone)
+	}
+
+	return
+}
+
+// Wraps inbound messages webhook: validates X-Twilio-Signature,
+// registers STOP/START keywords against tc.OptOuts, then calls next.
+// Requests with missing or invalid signature get 403. When next is
+// nil an empty TwiML response is written.
+func (tc *TwilioCfg) InboundHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "invalid form", http.StatusBadRequest)
+			return
+		}
+
+		if !ValidSignature(tc.AuthToken, tc.inboundUrl(r), r.PostForm, r.Header.Get("X-Twilio-Signature")) {
+			http.Error(w, "invalid signature", http.StatusForbidden)
+			return
+		}
+
+		if tc.OptOuts != nil {
+			if _, err := RegisterKeyword(tc.OptOuts, r.PostForm.Get("From"), r.PostForm.Get("Body")); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if next != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write([]byte("<Response></Response>"))
+	})
+}
+
+//------------------------------------------------------------
+// Signature
+//------------------------------------------------------------
+
+// Computes request signature: base64 HMAC-SHA1 of full url followed
+// by POST params sorted by name, each as name + value, keyed with
+// account auth token.
+func Signature(authToken, fullUrl string, params url.Values) string {
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(fullUrl))
+	for _, k := range keys {
+		for _, v := range params[k] {
+			mac.Write([]byte(k + v))
+		}
+	}
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Tells if signature sent as X-Twilio-Signature is valid.
+func ValidSignature(authToken, fullUrl string, params url.Values, signature string) bool {
+
+	if authToken == "" || signature == "" {
+		return false
+	}
+
+	expected := Signature(authToken, fullUrl, params)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// Url Twilio signed: InboundUrl when set, otherwise reconstructed
+// from request. Forwarded headers can be trusted here, a wrong url
+// only fails the signature.
+func (tc *TwilioCfg) inboundUrl(r *http.Request) string {
+
+	if tc.InboundUrl != "" {
+		return tc.InboundUrl
+	}
+
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	host := r.Host
+	if fh := r.Header.Get("X-Forwarded-Host"); fh != "" {
+		host = fh
+	}
+
+	return scheme + "://" + host + r.URL.RequestURI()
+}
+
+//------------------------------------------------------------
+// MemoryOptOuts
+//------------------------------------------------------------
+
+// MemoryOptOuts is process local OptOutRegistry.
+type MemoryOptOuts struct {
+	mu     sync.RWMutex
+	phones map[string]bool
+}
+
+func NewMemoryOptOuts() *MemoryOptOuts {
+	return &MemoryOptOuts{phones: map[string]bool{}}
+}
+
+func (mo *MemoryOptOuts) IsOptedOut(phone string) (bool, error) {
+
+	mo.mu.RLock()
+	defer mo.mu.RUnlock()
+
+	return mo.phones[cleanPhone(phone)], nil
+}
+
+func (mo *MemoryOptOuts) OptOut(phone string) error {
+
+	mo.mu.Lock()
+	defer mo.mu.Unlock()
+
+	mo.phones[cleanPhone(phone)] = true
+	return nil
+}
+
+func (mo *MemoryOptOuts) OptIn(phone string) error {
+
+	mo.mu.Lock()
+	defer mo.mu.Unlock()
+
+	delete(mo.phones, cleanPhone(phone))
+	return nil
+}
+
+//------------------------------------------------------------
+// FileOptOuts
+//------------------------------------------------------------
+
+// FileOptOuts is OptOutRegistry persisted to a text file,
+// one phone per line. The file is read once on open, opt-outs
+// are appended and opt-ins rewrite the file.
+type FileOptOuts struct {
+	mem  *MemoryOptOuts
+	path string
+	mu   sync.Mutex
+}
+
+// Opens registry file, creating it if missing.
+func NewFileOptOuts(path string) (fo *FileOptOuts, err error) {
+
+	var f *os.File
+	if f, err = os.OpenFile(path, os.O_RDONLY|os.O_CREATE, 0644); err != nil {
+		return
+	}
+	defer f.Close()
+
+	fo = &FileOptOuts{mem: NewMemoryOptOuts(), path: path}
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if phone := cleanPhone(sc.Text()); phone != "" {
+			fo.mem.phones[phone] = true
+		}
+	}
+
+	if err = sc.Err(); err != nil {
+		fo = nil
+	}
+
+	return
+}
+
+func (fo *FileOptOuts) IsOptedOut(phone string) (bool, error) {
+	return fo.mem.IsOptedOut(phone)
+}
+
+func (fo *FileOptOuts) OptOut(phone string) (err error) {
+
+	fo.mu.Lock()
+	defer fo.mu.Unlock()
+
+	if ok, _ := fo.mem.IsOptedOut(phone); ok {
+		return
+	}
+
+	var f *os.File
+	if f, err = os.OpenFile(fo.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644); err != nil {
+		return
+	}
+
+	if _, err = f.WriteString(cleanPhone(phone) + "\n"); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+
+	return fo.mem.OptOut(phone)
+}
+
+func (fo *FileOptOuts) OptIn(phone string) (err error) {
+
+	fo.mu.Lock()
+	defer fo.mu.Unlock()
+
+	if ok, _ := fo.mem.IsOptedOut(phone); !ok {
+		return
+	}
+
+	// Rewrite file without the phone
+	drop := cleanPhone(phone)
+	fo.mem.mu.RLock()
+	phones := make([]string, 0, len(fo.mem.phones))
+	for p := range fo.mem.phones {
+		if p != drop {
+			phones = append(phones, p)
+		}
+	}
+	fo.mem.mu.RUnlock()
+	sort.Strings(phones)
+
+	tmp := fo.path + ".tmp"
+	data := strings.Join(phones, "\n")
+	if len(phones) > 0 {
+		data += "\n"
+	}
+	if err = ioutil.WriteFile(tmp, []byte(data), 0644); err != nil {
+		return
+	}
+
+	if err = os.Rename(tmp, fo.path); err != nil {
+		return
+	}
+
+	return fo.mem.OptIn(phone)
+}
+
+//------------------------------------------------------------
+// Private methods
+//------------------------------------------------------------
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
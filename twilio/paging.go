@@ -0,0 +1,64 @@
+package twilio
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+//------------------------------------------------------------
+// Paging
+//------------------------------------------------------------
+
+// Paging information common to all Twilio list responses.
+type pageMeta struct {
+	Page        int    `json:"page"`
+	PageSize    int    `json:"page_size"`
+	NextPageUri string `json:"next_page_uri"`
+}
+
+func (pm *pageMeta) nextPage() string {
+	return pm.NextPageUri
+}
+
+type page interface {
+	nextPage() string
+}
+
+// Fetches list pages following next_page_uri until exhausted.
+type pager struct {
+	tc    *TwilioCfg
+	url   string
+	query url.Values
+	err   error
+}
+
+// Fetches next page into pg.
+// Returns false when there are no more pages or on error.
+func (p *pager) next(pg page) bool {
+
+	if p.err != nil || p.url == "" {
+		return false
+	}
+
+	var data []byte
+	data, p.err = p.tc.sendRequest("GET", p.url, p.query)
+	if p.err != nil {
+		return false
+	}
+
+	if err := json.Unmarshal(data, pg); err != nil {
+		p.err = fmt.Errorf("twilio list response: %v", err)
+		return false
+	}
+
+	// next_page_uri already carries the query
+	p.query = nil
+	if uri := pg.nextPage(); uri != "" {
+		p.url = apiBaseUrl + uri
+	} else {
+		p.url = ""
+	}
+
+	return true
+}
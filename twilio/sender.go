@@ -0,0 +1,158 @@
+package twilio
+
+/*
+
+example:
+
+var sender twilio.SMSSender = twilio.NewFailover(&tcPrimary, &tcBackup)
+
+err := sender.SMS("+XXXXX", "message")
+
+// in tests
+rec := &twilio.SMSRecorder{}
+sender = rec
+...
+rec.Sent[0].Body
+
+*/
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------
+// SMSSender
+//------------------------------------------------------------
+
+// SMSSender delivers a text message to phone.
+// Implemented by TwilioCfg, SMSRecorder and Failover.
+type SMSSender interface {
+	SMS(toPhone, body string) error
+}
+
+var _ SMSSender = (*TwilioCfg)(nil)
+
+//------------------------------------------------------------
+// SMSRecorder
+//------------------------------------------------------------
+
+// SMSRecorder is in-memory SMSSender for tests.
+// Messages are recorded instead of sent.
+type SMSRecorder struct {
+	Sent []RecordedSMS
+	Err  error // returned by SMS when set, nothing is recorded
+
+	mu sync.Mutex
+}
+
+// Single message captured by SMSRecorder.
+type RecordedSMS struct {
+	To   string
+	Body string
+	At   time.Time
+}
+
+func (rec *SMSRecorder) SMS(toPhone, body string) error {
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if rec.Err != nil {
+		return rec.Err
+	}
+
+	rec.Sent = append(rec.Sent, RecordedSMS{To: toPhone, Body: body, At: time.Now()})
+	return nil
+}
+
+// Last recorded message, zero value if none.
+func (rec *SMSRecorder) Last() (sms RecordedSMS) {
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if len(rec.Sent) > 0 {
+		sms = rec.Sent[len(rec.Sent)-1]
+	}
+
+	return
+}
+
+//------------------------------------------------------------
+// Failover
+//------------------------------------------------------------
+
+// Failover tries senders in order until one delivers.
+// ErrOptedOut is final and never fails over.
+type Failover struct {
+	Senders []SMSSender
+
+	mu        sync.Mutex
+	delivered SMSSender
+}
+
+// Error returned by Failover when every sender failed.
+type FailoverError struct {
+	Errs []error // one per sender, in order
+}
+
+func NewFailover(senders ...SMSSender) *Failover {
+	return &Failover{Senders: senders}
+}
+
+func (fo *Failover) SMS(toPhone, body string) (err error) {
+	_, err = fo.Send(toPhone, body)
+	return
+}
+
+// Sends via the first sender that succeeds and returns it.
+func (fo *Failover) Send(toPhone, body string) (delivered SMSSender, err error) {
+
+	ferr := &FailoverError{}
+	for _, s := range fo.Senders {
+		err = s.SMS(toPhone, body)
+		if err == nil {
+			fo.mu.Lock()
+			fo.delivered = s
+			fo.mu.Unlock()
+			return s, nil
+		}
+		if errors.Is(err, ErrOptedOut) {
+			return nil, err
+		}
+		ferr.Errs = append(ferr.Errs, err)
+	}
+
+	return nil, ferr
+}
+
+// Sender that delivered the last message, nil if none yet.
+func (fo *Failover) Delivered() SMSSender {
+
+	fo.mu.Lock()
+	defer fo.mu.Unlock()
+
+	return fo.delivered
+}
+
+func (fe *FailoverError) Error() string {
+
+	if len(fe.Errs) == 0 {
+		return "twilio failover: no senders"
+	}
+
+	ss := []string{}
+	for _, err := range fe.Errs {
+		ss = append(ss, err.Error())
+	}
+
+	return "twilio failover: all senders failed: " + strings.Join(ss, "; ")
+}
+
+// Unwrap lets errors.Is and errors.As match any sender error.
+func (fe *FailoverError) Unwrap() []error {
+	return fe.Errs
+}
@@ -0,0 +1,305 @@
+package twilio
+
+/*
+
+example, sms_templates.ini:
+
+[en]
+incident.p1 = P1 incident on {{.Service}}: {{.Summary}}
+signup.welcome = Welcome to Acme, {{.Name}}!
+
+[de]
+signup.welcome = Willkommen bei Acme, {{.Name}}!
+
+code:
+
+cat := twilio.NewCatalog()
+err := cat.LoadIni("sms_templates.ini")
+...
+tc.Templates = cat
+
+err = tc.SendTemplate("+XXXXX", "signup.welcome", "de-AT", map[string]string{"Name": "Jo"})
+
+*/
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+//------------------------------------------------------------
+// Errors
+//------------------------------------------------------------
+
+var (
+	ErrNoTemplate = errors.New("twilio: template not found")
+	ErrTooLong    = errors.New("twilio: message exceeds allowed segments")
+)
+
+//------------------------------------------------------------
+// Catalog
+//------------------------------------------------------------
+
+// Catalog holds named message templates per locale.
+type Catalog struct {
+	DefaultLocale string // used when no better locale matches
+	MaxSegments   int    // SMS segments a message may take
+
+	tpls map[string]map[string]*template.Template // locale -> name -> template
+}
+
+func NewCatalog() *Catalog {
+	return &Catalog{
+		DefaultLocale: "en",
+		MaxSegments:   1,
+		tpls:          map[string]map[string]*template.Template{},
+	}
+}
+
+// Adds template text under locale and name.
+// Fails when template does not parse or its static text alone
+// exceeds MaxSegments.
+func (c *Catalog) Add(locale, name, text string) (err error) {
+
+	var tpl *template.Template
+	if tpl, err = template.New(name).Option("missingkey=error").Parse(text); err != nil {
+		return
+	}
+
+	if c.MaxSegments > 0 {
+		if n := Segments(staticText(tpl)); n > c.MaxSegments {
+			return fmt.Errorf("twilio template %s/%s: %d segments, max %d", locale, name, n, c.MaxSegments)
+		}
+	}
+
+	locale = normLocale(locale)
+	if c.tpls == nil {
+		c.tpls = map[string]map[string]*template.Template{}
+	}
+	if c.tpls[locale] == nil {
+		c.tpls[locale] = map[string]*template.Template{}
+	}
+	c.tpls[locale][name] = tpl
+
+	return
+}
+
+// Loads templates from INI file: sections are locales,
+// keys are template names. Keys outside sections are ignored
+// so templates can share a file with other settings.
+func (c *Catalog) LoadIni(path string) (err error) {
+
+	var f *os.File
+	if f, err = os.Open(path); err != nil {
+		return
+	}
+	defer f.Close()
+
+	return c.loadIni(f, path)
+}
+
+// Loads templates from all INI files in fsys matching pattern,
+// ie embed.FS and "sms/*.ini".
+func (c *Catalog) LoadFS(fsys fs.FS, pattern string) (err error) {
+
+	var paths []string
+	if paths, err = fs.Glob(fsys, pattern); err != nil {
+		return
+	}
+
+	for _, path := range paths {
+		var f fs.File
+		if f, err = fsys.Open(path); err != nil {
+			return
+		}
+		err = c.loadIni(f, path)
+		f.Close()
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// Renders template for locale. Locale falls back from "pt-BR"
+// to "pt" to DefaultLocale.
+func (c *Catalog) Render(name, locale string, data interface{}) (body string, err error) {
+
+	tpl := c.lookup(name, locale)
+	if tpl == nil {
+		return "", fmt.Errorf("%w: %s/%s", ErrNoTemplate, locale, name)
+	}
+
+	var buf bytes.Buffer
+	if err = tpl.Execute(&buf, data); err != nil {
+		return
+	}
+	body = buf.String()
+
+	if c.MaxSegments > 0 && Segments(body) > c.MaxSegments {
+		return "", ErrTooLong
+	}
+
+	return
+}
+
+//------------------------------------------------------------
+// TwilioCfg methods: Templates
+//------------------------------------------------------------
+
+// Renders template from tc.Templates and texts it to phone.
+// Body is sent whole, unlike SMS it is never truncated: Catalog
+// MaxSegments bounds its length.
+func (tc *TwilioCfg) SendTemplate(toPhone, name, locale string, data interface{}) (err error) {
+
+	if tc.Templates == nil {
+		return ErrNoTemplate
+	}
+
+	var body string
+	if body, err = tc.Templates.Render(name, locale, data); err != nil {
+		return
+	}
+
+	return tc.sendSMS(toPhone, body)
+}
+
+//------------------------------------------------------------
+// Segments
+//------------------------------------------------------------
+
+// GSM 03.38 basic and extension (escaped, 2 septets) characters.
+const (
+	gsmBasic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+		"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	gsmExt = "^{}\\[~]|€\f"
+)
+
+// Number of SMS segments body takes: GSM-7 when every character
+// fits the GSM alphabet, UCS-2 otherwise.
+func Segments(body string) int {
+
+	septets, gsm := 0, true
+	for _, r := range body {
+		if strings.ContainsRune(gsmBasic, r) {
+			septets++
+		} else if strings.ContainsRune(gsmExt, r) {
+			septets += 2
+		} else {
+			gsm = false
+			break
+		}
+	}
+
+	var units, single, multi int
+	if gsm {
+		units, single, multi = septets, 160, 153
+	} else {
+		// UTF-16 code units
+		for _, r := range body {
+			units++
+			if r > 0xffff {
+				units++
+			}
+		}
+		single, multi = 70, 67
+	}
+
+	if units <= single {
+		return 1
+	}
+
+	return (units + multi - 1) / multi
+}
+
+//------------------------------------------------------------
+// Private methods
+//------------------------------------------------------------
+
+func (c *Catalog) loadIni(r io.Reader, path string) error {
+
+	locale := ""
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+			continue
+		case line[0] == '[' && line[len(line)-1] == ']':
+			locale = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		case locale == "":
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("%s:%d: expected name = text", path, n)
+		}
+
+		if err := c.Add(locale, strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])); err != nil {
+			return fmt.Errorf("%s:%d: %v", path, n, err)
+		}
+	}
+
+	return sc.Err()
+}
+
+// Finds template trying locale, its language, then default locale.
+func (c *Catalog) lookup(name, locale string) *template.Template {
+
+	locale = normLocale(locale)
+	candidates := []string{locale}
+	if i := strings.Index(locale, "-"); i > 0 {
+		candidates = append(candidates, locale[:i])
+	}
+	candidates = append(candidates, normLocale(c.DefaultLocale))
+
+	for _, l := range candidates {
+		if tpl := c.tpls[l][name]; tpl != nil {
+			return tpl
+		}
+	}
+
+	return nil
+}
+
+// Lower cases locale and uses dash separator: "pt_BR" -> "pt-br".
+func normLocale(locale string) string {
+	return strings.ToLower(strings.Replace(strings.TrimSpace(locale), "_", "-", -1))
+}
+
+// Concatenates literal text of template, ignoring actions.
+func staticText(tpl *template.Template) string {
+
+	var buf bytes.Buffer
+	var walk func(n parse.Node)
+	walk = func(n parse.Node) {
+		switch n := n.(type) {
+		case *parse.TextNode:
+			buf.Write(n.Text)
+		case *parse.ListNode:
+			if n != nil {
+				for _, child := range n.Nodes {
+					walk(child)
+				}
+			}
+		}
+	}
+
+	if tpl.Tree != nil {
+		walk(tpl.Tree.Root)
+	}
+
+	return buf.String()
+}
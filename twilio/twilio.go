@@ -27,10 +27,17 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 )
 
 const (
-	apiMsgUrl = "https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json"
+	apiBaseUrl    = "https://api.twilio.com"
+	apiMsgUrl     = apiBaseUrl + "/2010-04-01/Accounts/%s/Messages.json"
+	apiMsgItemUrl = apiBaseUrl + "/2010-04-01/Accounts/%s/Messages/%s.json"
+	apiCallUrl    = apiBaseUrl + "/2010-04-01/Accounts/%s/Calls.json"
+
+	// date filters format
+	dateFormat = "2006-01-02"
 )
 
 //------------------------------------------------------------
@@ -41,6 +48,16 @@ type TwilioCfg struct {
 	FromPhone  string // outgoing phone
 	AccountSID string // twilio accnt id
 	AuthToken  string // twilio token
+
+	OptOuts   OptOutRegistry // optional, consulted before each SMS
+	Templates *Catalog       // optional, used by SendTemplate
+
+	// Public url of inbound webhook as configured in Twilio, used to
+	// verify signatures. Reconstructed from request when empty.
+	InboundUrl string
+
+	BaseUrl    string       // optional, replaces https://*.twilio.com hosts, ie for tests
+	HTTPClient *http.Client // optional, defaults to new client per request
 }
 
 //------------------------------------------------------------
@@ -51,29 +68,50 @@ type TwilioCfg struct {
 // Send SMS
 //
 // silently ignore invalid numbers (numeric only)
-// if twilio response status is not 201
+// if recipient is in OptOuts return ErrOptedOut
+// if twilio response status is not 2xx
 //    put response body into err and return
 // else return nil assuming success
 //------------------------------------------------------------
 func (tc *TwilioCfg) SMS(toPhone, body string) (err error) {
 
-	var req *http.Request
-	var resp *http.Response
-
-	// Only allow [0...9] characters
-	toPhone = cleanPhone(toPhone)
-
-	// append +
-	toPhone = "+" + toPhone
-
 	// trim long msg
 	if len(body) >= 160 {
 		body = body[:155] + "..."
 	}
 
+	return tc.sendSMS(toPhone, body)
+}
+
+// Sends authenticated request to any Twilio API, ie Verify,
+// using BaseUrl and HTTPClient when set. Non-2xx responses are
+// returned as error holding the response body.
+func (tc *TwilioCfg) Request(method, apiUrl string, form url.Values) (data []byte, err error) {
+	return tc.sendRequest(method, apiUrl, form)
+}
+
+//------------------------------------------------------------
+// Private methods
+//------------------------------------------------------------
+
+// Sends SMS body as is, Twilio splits it into segments.
+// Recipients in OptOuts get ErrOptedOut.
+func (tc *TwilioCfg) sendSMS(toPhone, body string) (err error) {
+
+	// opted out recipients are never texted
+	if tc.OptOuts != nil {
+		var optedOut bool
+		if optedOut, err = tc.OptOuts.IsOptedOut(toPhone); err != nil {
+			return
+		}
+		if optedOut {
+			return ErrOptedOut
+		}
+	}
+
 	form := url.Values{
 		"To": {
-			toPhone,
+			E164Phone(toPhone),
 		},
 		"From": {
 			tc.FromPhone,
@@ -83,35 +121,83 @@ func (tc *TwilioCfg) SMS(toPhone, body string) (err error) {
 		},
 	}
 
-	postUrl := fmt.Sprintf(apiMsgUrl, tc.AccountSID)
-	req, err = http.NewRequest("POST", postUrl, bytes.NewBufferString(form.Encode()))
+	_, err = tc.sendRequest("POST", fmt.Sprintf(apiMsgUrl, tc.AccountSID), form)
+	return
+}
+
+// Sends request to twilio API using account basic auth.
+// Form is sent url-encoded in the body for POST requests and
+// as query string otherwise. Any non-2xx response status is
+// returned as error holding the response body.
+func (tc *TwilioCfg) sendRequest(method, apiUrl string, form url.Values) (data []byte, err error) {
+
+	var req *http.Request
+	var resp *http.Response
+
+	apiUrl = tc.rebase(apiUrl)
+
+	if method == "POST" {
+		req, err = http.NewRequest(method, apiUrl, bytes.NewBufferString(form.Encode()))
+	} else {
+		req, err = http.NewRequest(method, apiUrl, nil)
+	}
 
 	if err != nil {
-		return err
+		return
 	}
 
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	if method == "POST" {
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	} else if len(form) > 0 {
+		req.URL.RawQuery = form.Encode()
+	}
+	req.Header.Add("Accept", "application/json")
 	req.SetBasicAuth(tc.AccountSID, tc.AuthToken)
 
-	client := &http.Client{}
+	client := tc.HTTPClient
+	if client == nil {
+		client = &http.Client{}
+	}
 	resp, err = client.Do(req)
 
 	if err != nil {
-		return err
+		return
 	}
 
 	defer resp.Body.Close()
-	if resp.StatusCode != 201 {
-		respBody, ioerr := ioutil.ReadAll(resp.Body)
+	data, ioerr := ioutil.ReadAll(resp.Body)
 
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
 		if ioerr != nil {
-			return errors.New("twilio failed sending\nfailed to read http resp")
+			return nil, errors.New("twilio failed sending\nfailed to read http resp")
 		} else {
-			return errors.New("twilio failed sending: " + bytes.NewBuffer(respBody).String())
+			return nil, errors.New("twilio failed sending: " + bytes.NewBuffer(data).String())
 		}
 	}
 
-	return nil
+	return data, ioerr
+}
+
+// Formats phone as E.164: digits only, prefixed with +.
+func E164Phone(phone string) string {
+
+	// Only allow [0...9] characters
+	return "+" + cleanPhone(phone)
+}
+
+// Points Twilio API url at BaseUrl when set.
+func (tc *TwilioCfg) rebase(apiUrl string) string {
+
+	if tc.BaseUrl == "" {
+		return apiUrl
+	}
+
+	u, err := url.Parse(apiUrl)
+	if err != nil || !strings.HasSuffix(u.Host, ".twilio.com") {
+		return apiUrl
+	}
+
+	return tc.BaseUrl + u.RequestURI()
 }
 
 func validPhone(phone string) bool {
@@ -0,0 +1,279 @@
+package verify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deze333/alienplugs/twilio"
+)
+
+//------------------------------------------------------------
+// Constants
+//------------------------------------------------------------
+
+// Used by Local when fields are unset or too weak.
+const (
+	minDigits          = 6
+	maxDigits          = 8
+	defaultTTL         = 10 * time.Minute
+	defaultMaxAttempts = 5
+)
+
+//------------------------------------------------------------
+// Errors
+//------------------------------------------------------------
+
+var (
+	ErrRateLimited     = errors.New("verify: too many codes sent, try later")
+	ErrTooManyAttempts = errors.New("verify: too many failed attempts")
+	ErrExpired         = errors.New("verify: code expired")
+	ErrChannel         = errors.New("verify: channel not supported")
+)
+
+//------------------------------------------------------------
+// Local: provider-free fallback
+//------------------------------------------------------------
+
+// Caller places voice calls, ie *twilio.TwilioCfg.
+// When Local.Sender also implements Caller the call channel is available.
+type Caller interface {
+	Call(toPhone, twimlOrUrl string, opts *twilio.CallOpts) (*twilio.Call, error)
+}
+
+// Local generates, stores and checks TOTP-style codes itself
+// and delivers them via Sender. Used when Twilio Verify
+// is not available.
+type Local struct {
+	Sender      twilio.SMSSender
+	Store       Store
+	Digits      int           // code length, 6 to 8
+	TTL         time.Duration // code lifetime, 10 minutes when not set
+	MaxAttempts int           // failed checks allowed per code, 5 when not set
+	MaxSends    int           // codes allowed per SendWindow
+	SendWindow  time.Duration
+	Message     string // text with single %s for the code
+
+	mu sync.Mutex
+}
+
+// Creates fallback verifier with sensible defaults
+// and in-memory store.
+func NewLocal(sender twilio.SMSSender) *Local {
+	return &Local{
+		Sender:      sender,
+		Store:       NewMemoryStore(),
+		Digits:      minDigits,
+		TTL:         defaultTTL,
+		MaxAttempts: defaultMaxAttempts,
+		MaxSends:    3,
+		SendWindow:  15 * time.Minute,
+		Message:     "Your verification code is %s",
+	}
+}
+
+// Send implements Verifier: issues new code and delivers it.
+// Any previous code for the recipient is invalidated. Lock is
+// held only while the record is updated, not during delivery.
+func (lv *Local) Send(to string, channel Channel) (err error) {
+
+	key := recipient(to, channel)
+
+	var caller Caller
+	switch channel {
+	case ChannelSMS:
+	case ChannelCall:
+		var ok bool
+		if caller, ok = lv.Sender.(Caller); !ok {
+			return ErrChannel
+		}
+	default:
+		return ErrChannel
+	}
+
+	var prev, rec *Record
+	if prev, rec, err = lv.issue(key); err != nil {
+		return
+	}
+
+	code := hotp(rec.Secret, rec.Counter, lv.digits())
+	if caller != nil {
+		twiml := fmt.Sprintf("<Response><Say>%s</Say></Response>", fmt.Sprintf(lv.Message, spell(code)))
+		_, err = caller.Call(key, twiml, nil)
+	} else {
+		err = lv.Sender.SMS(key, fmt.Sprintf(lv.Message, code))
+	}
+
+	// Failed delivery restores previous code,
+	// unless another send replaced it meanwhile
+	if err != nil {
+		lv.mu.Lock()
+		defer lv.mu.Unlock()
+
+		if cur, gerr := lv.Store.Get(key); gerr == nil && cur != nil && bytes.Equal(cur.Secret, rec.Secret) {
+			if prev != nil {
+				lv.Store.Put(key, prev)
+			} else {
+				lv.Store.Delete(key)
+			}
+		}
+	}
+
+	return
+}
+
+// Check implements Verifier: tells if code matches the last one sent.
+// Successful check consumes the code.
+func (lv *Local) Check(to, code string) (ok bool, err error) {
+
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+
+	key := recipient(to, "")
+
+	var rec *Record
+	if rec, err = lv.Store.Get(key); err != nil || rec == nil || rec.Secret == nil {
+		return
+	}
+
+	if time.Now().After(rec.ExpiresAt) {
+		rec.Secret = nil
+		if err = lv.Store.Put(key, rec); err == nil {
+			err = ErrExpired
+		}
+		return
+	}
+
+	if rec.Attempts >= lv.maxAttempts() {
+		return false, ErrTooManyAttempts
+	}
+
+	expected := hotp(rec.Secret, rec.Counter, lv.digits())
+	ok = hmac.Equal([]byte(expected), []byte(strings.TrimSpace(code)))
+
+	// Consume code on success, count attempt otherwise.
+	// Send history is kept for rate limiting.
+	if ok {
+		rec.Secret = nil
+	} else {
+		rec.Attempts++
+	}
+	err = lv.Store.Put(key, rec)
+
+	return
+}
+
+//------------------------------------------------------------
+// Private methods
+//------------------------------------------------------------
+
+// Stores new code secret for key after checking send rate limit.
+// Returns record replaced, nil if none, and the new one.
+func (lv *Local) issue(key string) (prev, rec *Record, err error) {
+
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+
+	now := time.Now()
+	if prev, err = lv.Store.Get(key); err != nil {
+		return
+	}
+
+	// Rate limit
+	var recent []time.Time
+	if prev != nil {
+		for _, t := range prev.SentAt {
+			if now.Sub(t) < lv.SendWindow {
+				recent = append(recent, t)
+			}
+		}
+	}
+	if lv.MaxSends > 0 && len(recent) >= lv.MaxSends {
+		return nil, nil, ErrRateLimited
+	}
+
+	// New code
+	secret := make([]byte, 20)
+	if _, err = rand.Read(secret); err != nil {
+		return
+	}
+
+	rec = &Record{
+		Secret:    secret,
+		Counter:   uint64(now.Unix() / 30),
+		ExpiresAt: now.Add(lv.ttl()),
+		SentAt:    append(recent, now),
+	}
+	err = lv.Store.Put(key, rec)
+
+	return
+}
+
+// Code length, clamped to minDigits..maxDigits as RFC 4226
+// truncation yields at most 31 bits.
+func (lv *Local) digits() int {
+
+	if lv.Digits < minDigits {
+		return minDigits
+	}
+
+	if lv.Digits > maxDigits {
+		return maxDigits
+	}
+
+	return lv.Digits
+}
+
+// Code lifetime, defaultTTL when not set.
+func (lv *Local) ttl() time.Duration {
+
+	if lv.TTL <= 0 {
+		return defaultTTL
+	}
+
+	return lv.TTL
+}
+
+// Failed checks allowed, defaultMaxAttempts when not set.
+func (lv *Local) maxAttempts() int {
+
+	if lv.MaxAttempts <= 0 {
+		return defaultMaxAttempts
+	}
+
+	return lv.MaxAttempts
+}
+
+// HOTP code (RFC 4226) for given secret and counter.
+func hotp(secret []byte, counter uint64, digits int) string {
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation
+	off := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[off:off+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, bin%mod)
+}
+
+// Separates digits so text-to-speech reads them one by one.
+func spell(code string) string {
+	return strings.Join(strings.Split(code, ""), ", ")
+}
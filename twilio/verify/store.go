@@ -0,0 +1,76 @@
+package verify
+
+import (
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------
+// Store
+//------------------------------------------------------------
+
+// Record holds pending verification state of one recipient.
+// Codes are never stored, only the secret they are derived from.
+type Record struct {
+	Secret    []byte      // per verification HMAC key
+	Counter   uint64      // time step the code was issued at
+	ExpiresAt time.Time   // code is rejected after this moment
+	Attempts  int         // failed checks so far
+	SentAt    []time.Time // recent sends, for rate limiting
+}
+
+// Store keeps verification records keyed by recipient.
+type Store interface {
+	// Returns record or nil if there is none.
+	Get(key string) (rec *Record, err error)
+	Put(key string, rec *Record) error
+	Delete(key string) error
+}
+
+//------------------------------------------------------------
+// MemoryStore
+//------------------------------------------------------------
+
+// MemoryStore is process local Store, the default for Local.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: map[string]Record{}}
+}
+
+func (ms *MemoryStore) Get(key string) (rec *Record, err error) {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if r, ok := ms.records[key]; ok {
+		r.SentAt = append([]time.Time(nil), r.SentAt...)
+		rec = &r
+	}
+
+	return
+}
+
+func (ms *MemoryStore) Put(key string, rec *Record) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	r := *rec
+	r.SentAt = append([]time.Time(nil), rec.SentAt...)
+	ms.records[key] = r
+
+	return nil
+}
+
+func (ms *MemoryStore) Delete(key string) error {
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.records, key)
+	return nil
+}
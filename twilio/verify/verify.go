@@ -0,0 +1,162 @@
+package verify
+
+/*
+
+example (Twilio Verify):
+
+svc := verify.NewService(&tc, "VAXXXX")
+
+_, err := svc.Start("+XXXXX", verify.ChannelSMS)
+...
+ok, err := svc.Check("+XXXXX", code)
+
+example (provider-free fallback, codes sent via tc.SMS):
+
+lv := verify.NewLocal(&tc)
+
+err := lv.Send("+XXXXX", verify.ChannelSMS)
+...
+ok, err := lv.Check("+XXXXX", code)
+
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/deze333/alienplugs/twilio"
+)
+
+const (
+	apiVerificationsUrl = "https://verify.twilio.com/v2/Services/%s/Verifications"
+	apiVerifyCheckUrl   = "https://verify.twilio.com/v2/Services/%s/VerificationCheck"
+)
+
+//------------------------------------------------------------
+// Channels
+//------------------------------------------------------------
+
+// Channel used to deliver verification code.
+type Channel string
+
+const (
+	ChannelSMS   Channel = "sms"
+	ChannelCall  Channel = "call"
+	ChannelEmail Channel = "email"
+)
+
+// Verifier sends verification codes and checks them.
+// Implemented by Service (Twilio Verify) and Local (fallback).
+type Verifier interface {
+	Send(to string, channel Channel) error
+	Check(to, code string) (ok bool, err error)
+}
+
+//------------------------------------------------------------
+// Service: Twilio Verify
+//------------------------------------------------------------
+
+// Service talks to a Twilio Verify service.
+type Service struct {
+	Twilio     *twilio.TwilioCfg // account credentials
+	ServiceSid string            // verify service id, VAXXXX
+}
+
+// Verification represents a Twilio Verify verification resource.
+type Verification struct {
+	Sid         string `json:"sid"`
+	ServiceSid  string `json:"service_sid"`
+	To          string `json:"to"`
+	Channel     string `json:"channel"`
+	Status      string `json:"status"` // pending, approved, canceled
+	Valid       bool   `json:"valid"`
+	DateCreated string `json:"date_created"`
+	DateUpdated string `json:"date_updated"`
+}
+
+func NewService(tc *twilio.TwilioCfg, serviceSid string) *Service {
+	return &Service{Twilio: tc, ServiceSid: serviceSid}
+}
+
+// Starts verification: Twilio generates and delivers code to phone
+// or email via channel.
+func (s *Service) Start(to string, channel Channel) (v *Verification, err error) {
+
+	form := url.Values{
+		"To": {
+			recipient(to, channel),
+		},
+		"Channel": {
+			string(channel),
+		},
+	}
+
+	return s.post(fmt.Sprintf(apiVerificationsUrl, s.ServiceSid), form)
+}
+
+// Checks code entered by user. Verification is approved when
+// v.Status is "approved".
+func (s *Service) CheckCode(to, code string) (v *Verification, err error) {
+
+	form := url.Values{
+		"To": {
+			recipient(to, ""),
+		},
+		"Code": {
+			code,
+		},
+	}
+
+	return s.post(fmt.Sprintf(apiVerifyCheckUrl, s.ServiceSid), form)
+}
+
+// Send implements Verifier.
+func (s *Service) Send(to string, channel Channel) (err error) {
+	_, err = s.Start(to, channel)
+	return
+}
+
+// Check implements Verifier.
+func (s *Service) Check(to, code string) (ok bool, err error) {
+
+	var v *Verification
+	if v, err = s.CheckCode(to, code); err != nil {
+		return
+	}
+
+	return v.Status == "approved", nil
+}
+
+//------------------------------------------------------------
+// Private methods
+//------------------------------------------------------------
+
+// Posts form to Verify API and decodes verification.
+func (s *Service) post(postUrl string, form url.Values) (v *Verification, err error) {
+
+	var data []byte
+	if data, err = s.Twilio.Request("POST", postUrl, form); err != nil {
+		return
+	}
+
+	v = &Verification{}
+	if err = json.Unmarshal(data, v); err != nil {
+		return nil, fmt.Errorf("twilio verify response: %v", err)
+	}
+
+	return
+}
+
+// Normalizes recipient: emails are kept as is,
+// phones are formatted as E.164.
+func recipient(to string, channel Channel) string {
+
+	to = strings.TrimSpace(to)
+	if channel == ChannelEmail || strings.Contains(to, "@") {
+		return to
+	}
+
+	return twilio.E164Phone(to)
+}
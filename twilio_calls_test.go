@@ -0,0 +1,63 @@
+package alienplugs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/deze333/alienplugs/twilio"
+)
+
+func TestTwilioCall(t *testing.T) {
+
+	var forms []url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); r.URL.Path != "/2010-04-01/Accounts/AC1/Calls.json" || user != "AC1" || pass != "token" {
+			http.NotFound(w, r)
+			return
+		}
+		r.ParseForm()
+		forms = append(forms, r.PostForm)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"sid":"CA1","account_sid":"AC1","to":"+15550102030","from":"+15550000000","status":"queued","direction":"outbound-api"}`))
+	}))
+	defer srv.Close()
+
+	tc := twilio.TwilioCfg{FromPhone: "+15550000000", AccountSID: "AC1", AuthToken: "token", BaseUrl: srv.URL}
+
+	// TwiML with options
+	call, err := tc.Call("+1 555 010 2030", "<Response><Say>P1</Say></Response>", &twilio.CallOpts{
+		StatusCallback:       "https://example.com/status",
+		StatusCallbackEvents: []string{"answered", "completed"},
+		MachineDetection:     "Enable",
+		Timeout:              30,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if call.Sid != "CA1" || call.Status != "queued" || call.Direction != "outbound-api" {
+		t.Fatalf("Unexpected call: %+v", call)
+	}
+
+	form := forms[0]
+	if form.Get("To") != "+15550102030" || form.Get("From") != "+15550000000" ||
+		form.Get("Twiml") != "<Response><Say>P1</Say></Response>" || form.Get("Url") != "" {
+		t.Fatalf("Unexpected form: %v", form)
+	}
+	if !reflect.DeepEqual(form["StatusCallbackEvent"], []string{"answered", "completed"}) ||
+		form.Get("StatusCallback") != "https://example.com/status" ||
+		form.Get("MachineDetection") != "Enable" || form.Get("Timeout") != "30" {
+		t.Fatalf("Unexpected options: %v", form)
+	}
+
+	// TwiML url, no options
+	if _, err = tc.Call("+15550102030", "https://example.com/twiml.xml", nil); err != nil {
+		t.Fatal(err)
+	}
+	form = forms[1]
+	if form.Get("Url") != "https://example.com/twiml.xml" || form.Get("Twiml") != "" || form.Get("Timeout") != "" {
+		t.Fatalf("Unexpected form: %v", form)
+	}
+}
@@ -0,0 +1,51 @@
+package alienplugs
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deze333/alienplugs/twilio"
+)
+
+func TestTwilioListMessages(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/2010-04-01/Accounts/AC1/Messages.json" {
+			http.NotFound(w, r)
+			return
+		}
+
+		// first page carries filter, next_page_uri the rest
+		switch r.URL.Query().Get("PageToken") {
+		case "":
+			if r.URL.Query().Get("To") != "+15550102030" {
+				t.Errorf("Filter not sent: %s", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `{"page":0,"messages":[{"sid":"SM1"},{"sid":"SM2"}],`+
+				`"next_page_uri":"/2010-04-01/Accounts/AC1/Messages.json?To=%2B15550102030&Page=1&PageToken=PA2"}`)
+		case "PA2":
+			fmt.Fprint(w, `{"page":1,"messages":[],"next_page_uri":"/2010-04-01/Accounts/AC1/Messages.json?Page=2&PageToken=PA3"}`)
+		case "PA3":
+			fmt.Fprint(w, `{"page":2,"messages":[{"sid":"SM3"}],"next_page_uri":null}`)
+		}
+	}))
+	defer srv.Close()
+
+	tc := twilio.TwilioCfg{AccountSID: "AC1", AuthToken: "token", BaseUrl: srv.URL}
+	it := tc.ListMessages(twilio.MessageFilter{To: "1 555 010 2030"})
+
+	var sids []string
+	for it.Next() {
+		sids = append(sids, it.Message().Sid)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// empty page in the middle is skipped
+	if fmt.Sprint(sids) != "[SM1 SM2 SM3]" {
+		t.Fatalf("Unexpected messages: %v", sids)
+	}
+}
@@ -0,0 +1,77 @@
+package alienplugs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deze333/alienplugs/twilio"
+)
+
+func TestTwilioOptOut(t *testing.T) {
+
+	fname := filepath.Join(t.TempDir(), "optouts.txt")
+	fo, err := twilio.NewFileOptOuts(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := twilio.TwilioCfg{FromPhone: "+15550000000", AuthToken: "token", OptOuts: fo}
+	handler := tc.InboundHandler(nil)
+
+	inbound := func(body, token string) int {
+		form := url.Values{"From": {"+1 555 010 2030"}, "Body": {body}}
+		r := httptest.NewRequest("POST", "https://example.com/inbound?app=1", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if token != "" {
+			r.Header.Set("X-Twilio-Signature", twilio.Signature(token, "https://example.com/inbound?app=1", form))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// Unsigned STOP is rejected
+	if code := inbound("STOP", ""); code != http.StatusForbidden {
+		t.Fatalf("Expected unsigned request rejected, got %d", code)
+	}
+	if ok, _ := fo.IsOptedOut("+15550102030"); ok {
+		t.Fatal("Unsigned STOP registered")
+	}
+
+	// Inbound STOP reply
+	if code := inbound(" stop ", "token"); code != http.StatusOK {
+		t.Fatalf("Inbound handler failed: %d", code)
+	}
+
+	// Forged START does not opt back in
+	if code := inbound("START", "forged"); code != http.StatusForbidden {
+		t.Fatalf("Expected forged request rejected, got %d", code)
+	}
+
+	// Rejected before any request is made
+	if err = tc.SMS("15550102030", "hello"); err != twilio.ErrOptedOut {
+		t.Fatalf("Expected ErrOptedOut, got %v", err)
+	}
+
+	// Persisted across reopen
+	fo, err = twilio.NewFileOptOuts(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := fo.IsOptedOut("+15550102030"); !ok {
+		t.Fatal("Opt-out not persisted")
+	}
+
+	// START opts back in
+	if kw, err := twilio.RegisterKeyword(fo, "+15550102030", "Start"); kw != "START" || err != nil {
+		t.Fatalf("Expected START keyword, got %q, %v", kw, err)
+	}
+	fo, _ = twilio.NewFileOptOuts(fname)
+	if ok, _ := fo.IsOptedOut("+15550102030"); ok {
+		t.Fatal("Opt-in not persisted")
+	}
+}
@@ -0,0 +1,52 @@
+package alienplugs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/deze333/alienplugs/twilio"
+)
+
+func TestTwilioFailover(t *testing.T) {
+
+	down := &twilio.SMSRecorder{Err: errors.New("outage")}
+	backup := &twilio.SMSRecorder{}
+	fo := twilio.NewFailover(down, backup)
+
+	if err := fo.SMS("+15550102030", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if fo.Delivered() != backup || len(backup.Sent) != 1 {
+		t.Fatal("Expected backup sender to deliver")
+	}
+
+	// Opt-out is final
+	optedOut := &twilio.SMSRecorder{Err: twilio.ErrOptedOut}
+	fo = twilio.NewFailover(optedOut, backup)
+	if err := fo.SMS("+15550102030", "hello"); err != twilio.ErrOptedOut {
+		t.Fatalf("Expected ErrOptedOut, got %v", err)
+	}
+	if len(backup.Sent) != 1 {
+		t.Fatal("Opted out message failed over")
+	}
+
+	// Wrapped opt-out is final too
+	optedOut.Err = fmt.Errorf("provider: %w", twilio.ErrOptedOut)
+	if err := fo.SMS("+15550102030", "hello"); !errors.Is(err, twilio.ErrOptedOut) {
+		t.Fatalf("Expected ErrOptedOut, got %v", err)
+	}
+	if len(backup.Sent) != 1 {
+		t.Fatal("Wrapped opt-out message failed over")
+	}
+
+	// All down
+	fo = twilio.NewFailover(down, down)
+	err := fo.SMS("+15550102030", "hello")
+	if ferr, ok := err.(*twilio.FailoverError); !ok || len(ferr.Errs) != 2 {
+		t.Fatalf("Expected FailoverError with 2 errors, got %v", err)
+	}
+	if !errors.Is(err, down.Err) {
+		t.Fatalf("Expected FailoverError to unwrap to sender error, got %v", err)
+	}
+}
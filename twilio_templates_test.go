@@ -0,0 +1,109 @@
+package alienplugs
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"unicode/utf8"
+
+	"github.com/deze333/alienplugs/twilio"
+)
+
+const smsTemplatesIni = `
+account.SID = XXXXXXX
+
+[en]
+signup.welcome = Welcome, {{.Name}}!
+incident.p1 = P1 on {{.Service}}
+
+[de]
+signup.welcome = Willkommen, {{.Name}}!
+`
+
+func TestTwilioTemplates(t *testing.T) {
+
+	cat := twilio.NewCatalog()
+	fsys := fstest.MapFS{"sms/templates.ini": {Data: []byte(smsTemplatesIni)}}
+	if err := cat.LoadFS(fsys, "sms/*.ini"); err != nil {
+		t.Fatal(err)
+	}
+
+	tc := twilio.TwilioCfg{Templates: cat}
+
+	// Locale fallback: de_AT -> de, fr -> en
+	for locale, expected := range map[string]string{
+		"de_AT": "Willkommen, Jo!",
+		"fr":    "Welcome, Jo!",
+	} {
+		body, err := tc.Templates.Render("signup.welcome", locale, map[string]string{"Name": "Jo"})
+		if err != nil || body != expected {
+			t.Fatalf("Locale %v: expected %q, got %q, %v", locale, expected, body, err)
+		}
+	}
+
+	if _, err := cat.Render("missing", "en", nil); !errors.Is(err, twilio.ErrNoTemplate) {
+		t.Fatalf("Expected ErrNoTemplate, got %v", err)
+	}
+
+	// Static text over one segment is rejected at load time
+	if err := cat.Add("en", "long", strings.Repeat("a", 161)+"{{.X}}"); err == nil {
+		t.Fatal("Expected segment error")
+	}
+
+	for body, expected := range map[string]int{
+		strings.Repeat("a", 160): 1,
+		strings.Repeat("a", 161): 2,
+		strings.Repeat("€", 80):  1,
+		strings.Repeat("ж", 70):  1,
+		strings.Repeat("ж", 71):  2,
+	} {
+		if n := twilio.Segments(body); n != expected {
+			t.Fatalf("Expected %d segments for %q, got %d", expected, body, n)
+		}
+	}
+}
+
+func TestTwilioSendTemplate(t *testing.T) {
+
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		got = append(got, r.PostForm.Get("Body"))
+		w.Write([]byte(`{"sid":"SM1"}`))
+	}))
+	defer srv.Close()
+
+	// Non-ASCII 2 segment template, over 155 bytes
+	cat := twilio.NewCatalog()
+	cat.MaxSegments = 2
+	if err := cat.Add("ru", "notice", strings.Repeat("ж", 100)+" {{.Name}}"); err != nil {
+		t.Fatal(err)
+	}
+
+	tc := twilio.TwilioCfg{FromPhone: "+15550000000", Templates: cat, BaseUrl: srv.URL}
+	if err := tc.SendTemplate("+15550102030", "notice", "ru", map[string]string{"Name": "Жанна"}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Repeat("ж", 100) + " Жанна"
+	if len(got) != 1 || got[0] != expected || !utf8.ValidString(got[0]) {
+		t.Fatalf("Expected whole body %q, got %q", expected, got)
+	}
+}
+
+func TestTwilioCatalogZeroValue(t *testing.T) {
+
+	// Usable without NewCatalog
+	cat := &twilio.Catalog{DefaultLocale: "en"}
+	if err := cat.Add("en", "hello", "Hi {{.Name}}"); err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := cat.Render("hello", "fr", map[string]string{"Name": "Ann"})
+	if err != nil || body != "Hi Ann" {
+		t.Fatalf("Expected %q, got %q, %v", "Hi Ann", body, err)
+	}
+}
@@ -0,0 +1,187 @@
+package alienplugs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/deze333/alienplugs/twilio"
+	"github.com/deze333/alienplugs/twilio/verify"
+)
+
+// Last code sent, assumes default message format.
+func lastCode(rec *twilio.SMSRecorder) string {
+	return strings.TrimPrefix(rec.Last().Body, "Your verification code is ")
+}
+
+func TestVerifyLocal(t *testing.T) {
+
+	rec := &twilio.SMSRecorder{}
+	lv := verify.NewLocal(rec)
+
+	if err := lv.Send("+1 (555) 010-2030", verify.ChannelSMS); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Sent[0].To != "+15550102030" {
+		t.Fatalf("Expected E.164 recipient, got %v", rec.Sent[0].To)
+	}
+
+	code := lastCode(rec)
+	if len(code) != 6 {
+		t.Fatalf("Expected 6 digit code, got %q", code)
+	}
+
+	if ok, err := lv.Check("+15550102030", "000000x"); ok || err != nil {
+		t.Fatalf("Wrong code accepted: %v, %v", ok, err)
+	}
+	if ok, err := lv.Check("+15550102030", code); !ok || err != nil {
+		t.Fatalf("Correct code rejected: %v, %v", ok, err)
+	}
+	if ok, _ := lv.Check("+15550102030", code); ok {
+		t.Fatal("Code accepted twice")
+	}
+
+	if err := lv.Send("+15550102030", verify.ChannelEmail); err != verify.ErrChannel {
+		t.Fatalf("Expected ErrChannel, got %v", err)
+	}
+}
+
+func TestVerifyLocalLimits(t *testing.T) {
+
+	rec := &twilio.SMSRecorder{}
+	lv := verify.NewLocal(rec)
+	lv.MaxSends = 2
+	lv.MaxAttempts = 2
+
+	for i := 0; i < lv.MaxSends; i++ {
+		if err := lv.Send("+15550102030", verify.ChannelSMS); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := lv.Send("+15550102030", verify.ChannelSMS); err != verify.ErrRateLimited {
+		t.Fatalf("Expected ErrRateLimited, got %v", err)
+	}
+
+	code := lastCode(rec)
+	for i := 0; i < lv.MaxAttempts; i++ {
+		lv.Check("+15550102030", "x")
+	}
+	if _, err := lv.Check("+15550102030", code); err != verify.ErrTooManyAttempts {
+		t.Fatalf("Expected ErrTooManyAttempts, got %v", err)
+	}
+}
+
+func TestVerifyLocalDefaults(t *testing.T) {
+
+	// Zero value fields fall back to safe minimums
+	rec := &twilio.SMSRecorder{}
+	lv := &verify.Local{Sender: rec, Store: verify.NewMemoryStore(), Message: "Your verification code is %s"}
+
+	if err := lv.Send("+15550102030", verify.ChannelSMS); err != nil {
+		t.Fatal(err)
+	}
+	code := lastCode(rec)
+	if len(code) != 6 {
+		t.Fatalf("Expected 6 digit code, got %q", code)
+	}
+
+	for i := 0; i < 5; i++ {
+		if ok, err := lv.Check("+15550102030", "x"); ok || err != nil {
+			t.Fatalf("Unexpected check result: %v, %v", ok, err)
+		}
+	}
+	if _, err := lv.Check("+15550102030", code); err != verify.ErrTooManyAttempts {
+		t.Fatalf("Expected ErrTooManyAttempts, got %v", err)
+	}
+}
+
+func TestVerifyLocalMaxDigits(t *testing.T) {
+
+	// Lengths beyond RFC 4226 are clamped to 8 digits
+	for _, n := range []int{8, 10, 32, 40} {
+		rec := &twilio.SMSRecorder{}
+		lv := verify.NewLocal(rec)
+		lv.Digits = n
+
+		if err := lv.Send("+15550102030", verify.ChannelSMS); err != nil {
+			t.Fatal(err)
+		}
+		code := lastCode(rec)
+		if len(code) != 8 {
+			t.Fatalf("Digits %d: expected 8 digit code, got %q", n, code)
+		}
+		if ok, err := lv.Check("+15550102030", code); !ok || err != nil {
+			t.Fatalf("Digits %d: correct code rejected: %v, %v", n, ok, err)
+		}
+	}
+}
+
+func TestVerifyService(t *testing.T) {
+
+	var forms []url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "AC1" || pass != "token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		r.ParseForm()
+		forms = append(forms, r.PostForm)
+		switch r.URL.Path {
+		case "/v2/Services/VA1/Verifications":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"sid":"VE1","service_sid":"VA1","to":"+15550102030","channel":"sms","status":"pending"}`))
+		case "/v2/Services/VA1/VerificationCheck":
+			status := "pending"
+			if r.PostForm.Get("Code") == "123456" {
+				status = "approved"
+			}
+			w.Write([]byte(`{"sid":"VE1","service_sid":"VA1","to":"+15550102030","status":"` + status + `"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tc := twilio.TwilioCfg{AccountSID: "AC1", AuthToken: "token", BaseUrl: srv.URL}
+	svc := verify.NewService(&tc, "VA1")
+
+	v, err := svc.Start("+1 (555) 010-2030", verify.ChannelSMS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Sid != "VE1" || v.Status != "pending" {
+		t.Fatalf("Unexpected verification: %+v", v)
+	}
+	if forms[0].Get("To") != "+15550102030" || forms[0].Get("Channel") != "sms" {
+		t.Fatalf("Unexpected start form: %v", forms[0])
+	}
+
+	if _, err = svc.Start("user@example.com", verify.ChannelEmail); err != nil {
+		t.Fatal(err)
+	}
+	if forms[1].Get("To") != "user@example.com" || forms[1].Get("Channel") != "email" {
+		t.Fatalf("Unexpected email start form: %v", forms[1])
+	}
+
+	if v, err = svc.CheckCode("+15550102030", "000000"); err != nil || v.Status != "pending" {
+		t.Fatalf("Unexpected check result: %+v, %v", v, err)
+	}
+	if forms[2].Get("To") != "+15550102030" || forms[2].Get("Code") != "000000" {
+		t.Fatalf("Unexpected check form: %v", forms[2])
+	}
+
+	if ok, err := svc.Check("+15550102030", "123456"); !ok || err != nil {
+		t.Fatalf("Correct code rejected: %v, %v", ok, err)
+	}
+	if ok, err := svc.Check("+15550102030", "000000"); ok || err != nil {
+		t.Fatalf("Wrong code accepted: %v, %v", ok, err)
+	}
+
+	// Non-2xx is an error
+	tc.AuthToken = "wrong"
+	if ok, err := svc.Check("+15550102030", "123456"); ok || err == nil {
+		t.Fatalf("Expected error, got %v, %v", ok, err)
+	}
+}